@@ -0,0 +1,90 @@
+package gosmsg
+
+import (
+	"errors"
+	"strconv"
+)
+
+//ErrUnbalanced is returned when constructor scopes of a Builder are
+//not properly nested
+var ErrUnbalanced = errors.New("gosmsg: unbalanced constructor scopes")
+
+//A Builder builds a RawSMsg containing nested constructor tags.
+//Tags added between BeginConstructor/BeginVarLen and EndConstructor
+//become the content of that constructor.
+//
+//Only BeginConstructor, BeginVarLen and EndConstructor are tracked.
+//Variable length tags added with the embedded AddVariableTag and the
+//content added with AddRaw are not, so Terminate cannot detect
+//unterminated variable length tags added that way.
+type Builder struct {
+	RawSMsg
+	scopes []builderScope
+}
+
+type builderScope struct {
+	varLen bool
+	//offset of the constructor tag in Data
+	start int
+}
+
+//BeginConstructor starts a new constructor tag with a fixed length.
+//The length is filled in by the matching EndConstructor
+func (b *Builder) BeginConstructor(tag uint16) {
+	b.scopes = append(b.scopes, builderScope{false, len(b.Data)})
+	b.addImpl(tag|gConstructor, gVariableLen, []byte{})
+}
+
+//BeginVarLen starts a new variable length constructor tag.
+//The matching EndConstructor terminates it with an empty 0x0000 tag
+func (b *Builder) BeginVarLen(tag uint16) {
+	b.scopes = append(b.scopes, builderScope{true, len(b.Data)})
+	b.AddVariableTag(tag)
+}
+
+//EndConstructor ends the innermost open constructor.
+//ErrUnbalanced is returned if there is no open constructor
+func (b *Builder) EndConstructor() error {
+	if len(b.scopes) == 0 {
+		return ErrUnbalanced
+	}
+
+	scope := b.scopes[len(b.scopes)-1]
+	b.scopes = b.scopes[:len(b.scopes)-1]
+
+	if scope.varLen {
+		b.Add(0, []byte{})
+		return nil
+	}
+
+	// back-patch the length between the tag and the space
+	lenPos := scope.start + 4
+	dataLen := len(b.Data) - lenPos - 1
+	digits := strconv.AppendInt(nil, int64(dataLen), 10)
+	b.Data = append(b.Data, digits...)
+	copy(b.Data[lenPos+len(digits):], b.Data[lenPos:len(b.Data)-len(digits)])
+	copy(b.Data[lenPos:], digits)
+
+	return nil
+}
+
+//Depth returns the number of currently open constructors
+func (b *Builder) Depth() int {
+	return len(b.scopes)
+}
+
+//Terminate finishes building and returns a copy of the built RawSMsg,
+//which remains valid after Reset.
+//ErrUnbalanced is returned if there are still open constructors.
+func (b *Builder) Terminate() (RawSMsg, error) {
+	if len(b.scopes) != 0 {
+		return b.Clone(), ErrUnbalanced
+	}
+	return b.Clone(), nil
+}
+
+//Reset discards the built content so the Builder can be reused
+func (b *Builder) Reset() {
+	b.Data = b.Data[:0]
+	b.scopes = b.scopes[:0]
+}
@@ -0,0 +1,78 @@
+package gosmsg
+
+import (
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+
+	b.BeginVarLen(0x1019)
+	b.BeginConstructor(0x1222)
+	b.Add(0x1234, []byte("Hello"))
+	if b.Depth() != 2 {
+		t.Errorf("Got depth %d", b.Depth())
+	}
+	if err := b.EndConstructor(); err != nil {
+		t.Fatal(err)
+	}
+	b.Add(0x10, []byte("8"))
+	if err := b.EndConstructor(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := b.Terminate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(r.Data) != "9019 922211 12345 Hello00101 800000 " {
+		t.Error(string(r.Data))
+	}
+
+	b.Reset()
+	var inner RawSMsg
+	inner.Add(0x1234, []byte("Hello"))
+	inner.Add(0x10, []byte("8"))
+	var raw RawSMsg
+	raw.AddRaw(0x1019, &inner)
+
+	b.BeginConstructor(0x1019)
+	b.Add(0x1234, []byte("Hello"))
+	b.Add(0x10, []byte("8"))
+	b.EndConstructor()
+	r, err = b.Terminate()
+	if err != nil {
+		t.Fatal(err)
+	} else if string(r.Data) != string(raw.Data) {
+		t.Errorf("Got %q expected %q", r.Data, raw.Data)
+	}
+
+	prev := r
+	b.Reset()
+	b.Add(0x2, []byte("world"))
+	if string(prev.Data) != "901918 12345 Hello00101 8" {
+		t.Errorf("Reset modified a terminated RawSMsg, Got %q", prev.Data)
+	}
+
+	b.Reset()
+	b.BeginConstructor(0x1)
+	b.EndConstructor()
+	r, _ = b.Terminate()
+	if string(r.Data) != "80010 " {
+		t.Error(string(r.Data))
+	}
+}
+
+func TestBuilderUnbalanced(t *testing.T) {
+	var b Builder
+
+	if err := b.EndConstructor(); err != ErrUnbalanced {
+		t.Errorf("expected ErrUnbalanced, got %v", err)
+	}
+
+	b.BeginConstructor(0x1019)
+	b.Add(0x10, []byte("8"))
+	if _, err := b.Terminate(); err != ErrUnbalanced {
+		t.Errorf("expected ErrUnbalanced, got %v", err)
+	}
+}
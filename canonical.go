@@ -0,0 +1,71 @@
+package gosmsg
+
+import (
+	"sort"
+)
+
+//Canonicalize returns the SMsg rewritten into a canonical form, so
+//that equivalent messages can be compared byte by byte:
+//
+//	- all constructors have a fixed length, variable length constructors
+//	  are rewritten and their terminating 0x0000 tags removed
+//	- the tags at each nesting level are sorted by tag number, tags with
+//	  the same number keep their relative order
+//	- the message ends with a single empty 0x0000 terminator tag
+//
+//The SMsg is validated first, see Validate, and any error is returned.
+func (s *RawSMsg) Canonicalize() (RawSMsg, error) {
+	if err := s.Validate(); err != nil {
+		return RawSMsg{}, err
+	}
+
+	c, err := canonicalTags(s.Data, 0)
+	if err != nil {
+		return RawSMsg{}, err
+	}
+	c.Add(0, []byte{})
+	return c, nil
+}
+
+func canonicalTags(data []byte, base int) (RawSMsg, error) {
+	var c RawSMsg
+
+	tags, err := childTags(data, base)
+	if err != nil {
+		return c, err
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	for _, t := range tags {
+		switch {
+		case t.Constructor:
+			inner, err := canonicalTags(t.Data, t.dataOff)
+			if err != nil {
+				return c, err
+			}
+			c.AddRaw(t.Tag, &inner)
+		case t.Tag == 0 && len(t.Data) == 0:
+			//terminator
+		default:
+			c.Add(t.Tag, t.Data)
+		}
+	}
+
+	return c, nil
+}
+
+//EqualCanonical reports whether s and o are equal in their canonical
+//form, see Canonicalize. An error is returned if either is invalid.
+func (s *RawSMsg) EqualCanonical(o *RawSMsg) (bool, error) {
+	cs, err := s.Canonicalize()
+	if err != nil {
+		return false, err
+	}
+	co, err := o.Canonicalize()
+	if err != nil {
+		return false, err
+	}
+	return cs.Equal(&co), nil
+}
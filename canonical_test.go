@@ -0,0 +1,57 @@
+package gosmsg
+
+import (
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		in  string
+		exp string
+	}{
+		{"", "00000 "},
+		{"00102 hi00015 Hello00000 ", "00015 Hello00102 hi00000 "},
+		{"9019 922211 12345 Hello00101 800000 ", "901925 00101 8922211 12345 Hello00000 "},
+		{"901918 922211 12345 Hello00101 8", "00101 8901918 922211 12345 Hello00000 "},
+		{"9019 9222 00103 end00015 Hello00000 00000 ", "901927 922220 00015 Hello00103 end00000 "},
+		{"00011 b00011 a", "00011 b00011 a00000 "},
+	}
+
+	for _, test := range tests {
+		r := RawSMsg{[]byte(test.in)}
+		c, err := r.Canonicalize()
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+		} else if string(c.Data) != test.exp {
+			t.Errorf("%q: Got %q expected %q", test.in, c.Data, test.exp)
+		}
+
+		cc, err := c.Canonicalize()
+		if err != nil || string(cc.Data) != string(c.Data) {
+			t.Errorf("%q: not idempotent, Got %q", test.in, cc.Data)
+		}
+	}
+
+	r := RawSMsg{[]byte("9019 00101 8")}
+	if _, err := r.Canonicalize(); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestEqualCanonical(t *testing.T) {
+	a := RawSMsg{[]byte("9019 922211 12345 Hello00101 800000 ")}
+	b := RawSMsg{[]byte("901925 00101 8922211 12345 Hello00000 ")}
+	c := RawSMsg{[]byte("901925 00101 9922211 12345 Hello00000 ")}
+
+	if eq, err := a.EqualCanonical(&b); err != nil || !eq {
+		t.Errorf("Got %t %v", eq, err)
+	}
+	if eq, err := a.EqualCanonical(&c); err != nil || eq {
+		t.Errorf("Got %t %v", eq, err)
+	}
+
+	bad := RawSMsg{[]byte("9019 ")}
+	if _, err := a.EqualCanonical(&bad); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,74 @@
+package gosmsg
+
+import (
+	"bytes"
+	"errors"
+	"math"
+)
+
+//ErrConstructorBit is returned when a tag number has the constructor
+//bit (0x8000) set
+var ErrConstructorBit = errors.New("gosmsg: tag number has the constructor bit set")
+
+//ErrTooLong is returned when tag data exceeds the maximum length
+var ErrTooLong = errors.New("gosmsg: tag data too long")
+
+//ErrVarLenData is returned by AddTagChecked for a variable length
+//tag with data
+var ErrVarLenData = errors.New("gosmsg: variable length tag with data")
+
+func checkTagData(tag uint16, data []byte, maxLen int) error {
+	if maxLen <= 0 || maxLen > math.MaxInt32 {
+		maxLen = math.MaxInt32
+	}
+
+	if tag&gConstructor != 0 {
+		return ErrConstructorBit
+	} else if len(data) > maxLen {
+		return ErrTooLong
+	} else if bytes.IndexAny(data, "\r\n") != -1 {
+		return ErrEmbeddedNewline
+	}
+	return nil
+}
+
+//AddChecked is like Add, but returns an error instead of masking
+//the constructor bit of tag or adding data containing newlines
+//or longer than maxLen. A maxLen of 0 allows the maximum length Iter
+//can parse. Nothing is added on error.
+func (s *RawSMsg) AddChecked(tag uint16, data []byte, maxLen int) error {
+	if err := checkTagData(tag, data, maxLen); err != nil {
+		return err
+	}
+	s.Add(tag, data)
+	return nil
+}
+
+//AddTagChecked adds t, e.g. a Tag from another SMsg, with the same
+//checks as AddChecked. Constructor tags are added with their data as
+//is, which must be valid, see Validate. Variable length tags must be
+//constructors without data, the data of such tags from Iter must be
+//added separately. Nothing is added on error.
+func (s *RawSMsg) AddTagChecked(t Tag, maxLen int) error {
+	if err := checkTagData(t.Tag, t.Data, maxLen); err != nil {
+		return err
+	}
+
+	switch {
+	case t.VarLen && !t.Constructor:
+		return ErrVarLenPrimitive
+	case t.VarLen && len(t.Data) > 0:
+		return ErrVarLenData
+	case t.VarLen:
+		s.AddVariableTag(t.Tag)
+	case t.Constructor:
+		r := RawSMsg{t.Data}
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		s.AddRaw(t.Tag, &r)
+	default:
+		s.Add(t.Tag, t.Data)
+	}
+	return nil
+}
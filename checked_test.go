@@ -0,0 +1,75 @@
+package gosmsg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddChecked(t *testing.T) {
+	var r RawSMsg
+
+	if err := r.AddChecked(0x1234, []byte("Hello"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddChecked(0x8010, []byte("8"), 0); err != ErrConstructorBit {
+		t.Errorf("Got %v", err)
+	}
+	if err := r.AddChecked(0x10, []byte("8\n"), 0); err != ErrEmbeddedNewline {
+		t.Errorf("Got %v", err)
+	}
+	if err := r.AddChecked(0x10, []byte("1234"), 3); err != ErrTooLong {
+		t.Errorf("Got %v", err)
+	}
+	if err := r.AddChecked(0x10, []byte("123"), 3); err != nil {
+		t.Error(err)
+	}
+
+	if string(r.Data) != "12345 Hello00103 123" {
+		t.Error(string(r.Data))
+	}
+}
+
+func TestAddTagChecked(t *testing.T) {
+	src := RawSMsg{[]byte("9019 922211 12345 Hello00101 800000 ")}
+
+	var r RawSMsg
+	it := src.Tags()
+	for {
+		tag, err := it.NextTag()
+		if err != nil {
+			break
+		}
+		if tag.VarLen {
+			tag.Data = nil
+		}
+		if err := r.AddTagChecked(tag, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !r.Equal(&src) {
+		t.Errorf("Got %q", r.Data)
+	}
+
+	tests := []struct {
+		tag Tag
+		err error
+	}{
+		{Tag{Tag: 0x1, VarLen: true}, ErrVarLenPrimitive},
+		{Tag{Tag: 0x1, VarLen: true, Constructor: true, Data: []byte("00101 8")}, ErrVarLenData},
+		{Tag{Tag: 0x8001, Data: []byte("8")}, ErrConstructorBit},
+		{Tag{Tag: 0x1, Data: []byte("\r")}, ErrEmbeddedNewline},
+		{Tag{Tag: 0x1, Constructor: true, Data: []byte("0010 8")}, ErrVarLenPrimitive},
+	}
+	if err := r.AddTagChecked(Tag{Tag: 0x1, Data: []byte("12")}, 1); err != ErrTooLong {
+		t.Errorf("Got %v", err)
+	}
+	for _, test := range tests {
+		n := len(r.Data)
+		if err := r.AddTagChecked(test.tag, 0); !errors.Is(err, test.err) {
+			t.Errorf("%s: Got %v expected %v", &test.tag, err, test.err)
+		}
+		if len(r.Data) != n {
+			t.Errorf("%s: data added", &test.tag)
+		}
+	}
+}
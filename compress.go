@@ -0,0 +1,79 @@
+package gosmsg
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var gGzipMagic = []byte{0x1f, 0x8b}
+var gZstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+//NewRawSMsgReaderAuto returns a new RawSMsgReader reading from r.
+//If r starts with a gzip or zstd header, the input is transparently
+//decompressed, otherwise it is read as is.
+//Close should be called when done reading to release the decompressor.
+//r is wrapped in a *bufio.Reader unless it already is a *bufio.Reader
+func NewRawSMsgReaderAuto(r io.Reader, opts ...ReaderOption) (RawSMsgReader, error) {
+	bufR, ok := r.(*bufio.Reader)
+	if !ok {
+		bufR = bufio.NewReader(r)
+	}
+
+	magic, err := bufR.Peek(len(gZstdMagic))
+	if err != nil && err != io.EOF {
+		return RawSMsgReader{}, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gGzipMagic):
+		zr, err := gzip.NewReader(bufR)
+		if err != nil {
+			return RawSMsgReader{}, err
+		}
+		rr := NewRawSMsgReader(zr, opts...)
+		rr.closer = zr
+		return rr, nil
+	case bytes.HasPrefix(magic, gZstdMagic):
+		zr, err := zstd.NewReader(bufR, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return RawSMsgReader{}, err
+		}
+		rc := zr.IOReadCloser()
+		rr := NewRawSMsgReader(rc, opts...)
+		rr.closer = rc
+		return rr, nil
+	}
+
+	return NewRawSMsgReader(bufR, opts...), nil
+}
+
+//NewRawSMsgWriterGzip returns a new RawSMsgWriter writing gzip compressed
+//output to w, using the given compression level, see compress/gzip.
+//Close must be called to complete the gzip stream.
+func NewRawSMsgWriterGzip(w io.Writer, level int) (RawSMsgWriter, error) {
+	zw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return RawSMsgWriter{}, err
+	}
+	ww := NewRawSMsgWriter(zw)
+	ww.closer = zw
+	return ww, nil
+}
+
+//NewRawSMsgWriterZstd returns a new RawSMsgWriter writing zstd compressed
+//output to w, using the given zstd compression level from 1 to 22,
+//see zstd.EncoderLevelFromZstd.
+//Close must be called to complete the zstd stream.
+func NewRawSMsgWriterZstd(w io.Writer, level int) (RawSMsgWriter, error) {
+	zw, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	if err != nil {
+		return RawSMsgWriter{}, err
+	}
+	ww := NewRawSMsgWriter(zw)
+	ww.closer = zw
+	return ww, nil
+}
@@ -0,0 +1,125 @@
+package gosmsg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func readAll(t *testing.T, r RawSMsgReader) []string {
+	var msgs []string
+	for {
+		smsg, err := r.ReadRawSMsg()
+		if err == io.EOF {
+			return msgs
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, string(smsg.Data))
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	var b bytes.Buffer
+
+	w, err := NewRawSMsgWriterGzip(&b, gzip.BestSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteRawSMsg(RawSMsg{[]byte("10015 hello")})
+	w.WriteRawSMsg(RawSMsg{[]byte("10015 world")})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b.Bytes(), []byte{0x1f, 0x8b}) {
+		t.Error("output not gzip compressed")
+	}
+
+	r, err := NewRawSMsgReaderAuto(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := readAll(t, r)
+	if len(msgs) != 2 || msgs[0] != "10015 hello" || msgs[1] != "10015 world" {
+		t.Errorf("Got %q", msgs)
+	}
+}
+
+func TestReaderAutoPlain(t *testing.T) {
+	tests := []struct {
+		in  string
+		exp int
+	}{
+		{"10015 hello\n10015 world\n", 2},
+		{"\n", 1},
+		{"", 0},
+	}
+	for _, test := range tests {
+		r, err := NewRawSMsgReaderAuto(bytes.NewBufferString(test.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msgs := readAll(t, r); len(msgs) != test.exp {
+			t.Errorf("%q: Got %q", test.in, msgs)
+		}
+	}
+}
+
+func TestCompressRoundTripZstd(t *testing.T) {
+	var b bytes.Buffer
+
+	w, err := NewRawSMsgWriterZstd(&b, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		w.WriteRawSMsg(RawSMsg{[]byte("10015 hello")})
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b.Bytes(), []byte{0x28, 0xb5, 0x2f, 0xfd}) || b.Len() >= 1200 {
+		t.Errorf("output not zstd compressed, %d bytes", b.Len())
+	}
+
+	r, err := NewRawSMsgReaderAuto(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	msgs := readAll(t, r)
+	if len(msgs) != 100 || msgs[0] != "10015 hello" || msgs[99] != "10015 hello" {
+		t.Errorf("Got %d %q", len(msgs), msgs[0])
+	}
+}
+
+func TestReaderAutoZstd(t *testing.T) {
+	//20 lines of "10015 hello", compressed by the zstd command line tool
+	in := []byte{
+		0x28, 0xb5, 0x2f, 0xfd, 0x04, 0x68, 0xa5, 0x00, 0x00, 0x68, 0x31, 0x30, 0x30, 0x31, 0x35, 0x20,
+		0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x0a, 0x31, 0x01, 0x00, 0xe0, 0x53, 0xe5, 0x0b, 0x43, 0x14, 0x96,
+		0xd3,
+	}
+	r, err := NewRawSMsgReaderAuto(bytes.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := readAll(t, r)
+	if len(msgs) != 20 || msgs[19] != "10015 hello" {
+		t.Errorf("Got %q", msgs)
+	}
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+
+	//corrupt input
+	r, err = NewRawSMsgReaderAuto(bytes.NewReader(in[:20]))
+	if err == nil {
+		_, err = r.ReadRawSMsg()
+		r.Close()
+	}
+	if err == nil || err == io.EOF {
+		t.Errorf("expected error, got %v", err)
+	}
+}
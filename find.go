@@ -0,0 +1,48 @@
+package gosmsg
+
+import (
+	"errors"
+	"iter"
+)
+
+//ErrNotFound is returned by Find when the tag does not occur in the SMsg
+var ErrNotFound = errors.New("gosmsg: tag not found")
+
+func (s *RawSMsg) tagSeq(recursive bool) iter.Seq2[Tag, error] {
+	if recursive {
+		return s.AllTagsRecursive()
+	}
+	return s.AllTags()
+}
+
+//Find returns the first occurrence of tag.
+//If recursive is true the subtags of fixed length constructors are
+//searched as well, see Iter.AllRecursive.
+//ErrNotFound is returned if tag does not occur, parsing stops at the
+//first match so errors after it are not detected.
+func (s *RawSMsg) Find(tag uint16, recursive bool) (Tag, error) {
+	for t, err := range s.tagSeq(recursive) {
+		if err != nil {
+			return Tag{}, err
+		} else if t.Tag == tag {
+			return t, nil
+		}
+	}
+	return Tag{}, ErrNotFound
+}
+
+//FindAll returns all occurrences of tag in the order they occur.
+//If recursive is true the subtags of fixed length constructors are
+//searched as well, see Iter.AllRecursive.
+//The returned slice is empty if tag does not occur.
+func (s *RawSMsg) FindAll(tag uint16, recursive bool) ([]Tag, error) {
+	var tags []Tag
+	for t, err := range s.tagSeq(recursive) {
+		if err != nil {
+			return tags, err
+		} else if t.Tag == tag {
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,47 @@
+package gosmsg
+
+import (
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	r := RawSMsg{[]byte("9019 922211 12345 Hello00101 800000 00102 hi")}
+
+	tag, err := r.Find(0x10, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(tag.Data) != "8" {
+		t.Errorf("Got %s", &tag)
+	}
+
+	if _, err = r.Find(0x1234, false); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	tag, err = r.Find(0x1234, true)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(tag.Data) != "Hello" {
+		t.Errorf("Got %s", &tag)
+	}
+
+	tags, err := r.FindAll(0x10, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(tags) != 2 || string(tags[0].Data) != "8" || string(tags[1].Data) != "hi" {
+		t.Errorf("Got %v", tags)
+	}
+
+	tags, err = r.FindAll(0x4321, true)
+	if err != nil || len(tags) != 0 {
+		t.Errorf("Got %v %v", tags, err)
+	}
+
+	bad := RawSMsg{[]byte("00101 8H0012 hi")}
+	if _, err = bad.FindAll(0x10, false); err == nil {
+		t.Error("expected error")
+	}
+	if _, err = bad.Find(0x10, false); err != nil {
+		t.Error(err)
+	}
+}
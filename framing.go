@@ -0,0 +1,231 @@
+package gosmsg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+//A ReaderOption configures a RawSMsgReader, see NewRawSMsgReader
+type ReaderOption func(r *RawSMsgReader)
+
+var gNewline = []byte{'\n'}
+
+//WithDelimiter separates messages by delim, e.g. []byte{0} for NUL
+//separated messages. Unlike the default newline framing a \r before
+//delim is not removed. An empty delim selects the default framing.
+func WithDelimiter(delim []byte) ReaderOption {
+	return func(r *RawSMsgReader) {
+		r.terminated = false
+		r.delim = nil
+		if len(delim) > 0 {
+			r.delim = append([]byte{}, delim...)
+		}
+	}
+}
+
+//WithTerminatorFraming reads messages without separators, each message
+//ends with the empty 0x0000 terminator tag that is not within a variable
+//length constructor. The terminator is part of the returned RawSMsg.
+//Newlines between messages are skipped.
+//Since framing is lost on a malformed tag, any such error is permanent
+//unless WithResync is used.
+func WithTerminatorFraming() ReaderOption {
+	return func(r *RawSMsgReader) {
+		r.terminated = true
+		r.delim = nil
+	}
+}
+
+//WithZeroCopy makes the Data of the returned RawSMsgs valid only until
+//the next call to ReadRawSMsg, avoiding an allocation per message.
+//By default each RawSMsg is a copy owned by the caller.
+func WithZeroCopy() ReaderOption {
+	return func(r *RawSMsgReader) {
+		r.zeroCopy = true
+	}
+}
+
+//WithResync removes malformed data from messages instead of returning
+//messages failing Validate. Like Iter.EnableResync, the data from a
+//malformed tag up to the next plausible tag is skipped. The tag of an
+//unterminated variable length constructor is removed, keeping its
+//content. Messages with nothing left are skipped entirely.
+//With WithTerminatorFraming, the malformed data is skipped while reading
+//so that the framing is regained.
+//The skipped data is reported by RawSMsgReader.Skipped.
+func WithResync() ReaderOption {
+	return func(r *RawSMsgReader) {
+		r.resync = true
+	}
+}
+
+func (r *RawSMsgReader) readLine() ([]byte, error) {
+	delim := r.delim
+	if delim == nil {
+		delim = gNewline
+	}
+	last := delim[len(delim)-1]
+
+	l, err := r.R.ReadSlice(last)
+	if err != bufio.ErrBufferFull && (len(delim) == 1 || err != nil || bytes.HasSuffix(l, delim)) {
+		if r.zeroCopy {
+			return l, err
+		}
+		return append([]byte(nil), l...), err
+	}
+
+	//message longer than the bufio.Reader buffer, or a
+	//partial match of a multi byte delimiter
+	r.buf = append(r.buf[:0], l...)
+	for err == bufio.ErrBufferFull || (err == nil && !bytes.HasSuffix(r.buf, delim)) {
+		l, err = r.R.ReadSlice(last)
+		r.buf = append(r.buf, l...)
+	}
+	if r.zeroCopy {
+		return r.buf, err
+	}
+	return append([]byte(nil), r.buf...), err
+}
+
+//readTerminated reads a message ended by the 0x0000 terminator tag
+//and returns it along with the number of bytes consumed
+func (r *RawSMsgReader) readTerminated() ([]byte, int, error) {
+	n := 0
+	for {
+		b, err := r.R.ReadByte()
+		if err != nil {
+			return nil, n, err
+		} else if b != '\n' && b != '\r' {
+			r.R.UnreadByte()
+			break
+		}
+		n++
+	}
+
+	r.buf = r.buf[:0]
+	//number of open variable length constructors
+	depth := 0
+	for {
+		tagOff := len(r.buf)
+		t, err := r.readTag()
+		if err == nil && r.resync {
+			if cerr := checkTag(&t, r.buf[tagOff:], tagOff, depth); cerr != nil {
+				//drop the tag, which has been read
+				r.skipped = append(r.skipped, SkippedRange{r.off + int64(n+tagOff), len(r.buf) - tagOff, cerr})
+				n += len(r.buf) - tagOff
+				r.buf = r.buf[:tagOff]
+				continue
+			}
+		}
+
+		var perr *ParseError
+		if r.resync && errors.As(err, &perr) {
+			//the malformed tag header has not been read
+			skip, serr := r.skipMalformed()
+			r.skipped = append(r.skipped, SkippedRange{r.off + int64(n+tagOff), skip, err})
+			n += skip
+			if serr == nil {
+				continue
+			}
+			err = serr
+			if err == io.EOF && len(r.buf) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		if err != nil {
+			return nil, n + len(r.buf), err
+		}
+
+		switch {
+		case t.VarLen:
+			depth++
+		case t.Tag == 0 && !t.Constructor && len(t.Data) == 0:
+			if depth == 0 {
+				n += len(r.buf)
+				if r.zeroCopy {
+					return r.buf, n, nil
+				}
+				return append([]byte(nil), r.buf...), n, nil
+			}
+			depth--
+		}
+	}
+}
+
+//readTag reads a tag and its data, appending it to r.buf.
+//io.EOF is only returned if r.buf is empty and the stream ends.
+//A malformed tag header is left unread, so resync can continue from
+//the start of it.
+func (r *RawSMsgReader) readTag() (t Tag, err error) {
+	start := len(r.buf)
+	parseError := func(tag int, hdr []byte, err error) error {
+		return &ParseError{Offset: start, Tag: tag, Near: nearBytes(hdr), Err: err}
+	}
+	//the stream ended or failed within the header
+	readError := func(hdr []byte, err error) error {
+		r.buf = append(r.buf, hdr...)
+		r.R.Discard(len(hdr))
+		if err == io.EOF && len(r.buf) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	hdr, err := r.R.Peek(4)
+	if err != nil {
+		return t, readError(hdr, err)
+	}
+	tag, err := strconv.ParseUint(string(hdr), 16, 16)
+	if err != nil {
+		return t, parseError(-1, hdr, err)
+	}
+	t.Constructor = uint16(tag)&gConstructor != 0
+	t.Tag = uint16(tag) & ^gConstructor
+
+	//peek one byte at a time to not wait for data past the header
+	for n := 5; ; n++ {
+		if hdr, err = r.R.Peek(n); err != nil {
+			return t, readError(hdr, err)
+		} else if hdr[n-1] == ' ' {
+			break
+		} else if n-4 > gMaxLenDigits {
+			return t, parseError(int(t.Tag), hdr, strconv.ErrRange)
+		}
+	}
+
+	var dataLen int64
+	if len(hdr) == 5 {
+		if !t.Constructor {
+			return t, parseError(int(t.Tag), hdr, ErrVarLenPrimitive)
+		}
+		t.VarLen = true
+	} else {
+		dataLen, err = strconv.ParseInt(string(hdr[4:len(hdr)-1]), 10, 32)
+		if err != nil {
+			return t, parseError(int(t.Tag), hdr, err)
+		} else if dataLen < 0 {
+			return t, parseError(int(t.Tag), hdr, strconv.ErrRange)
+		}
+	}
+	r.buf = append(r.buf, hdr...)
+	r.R.Discard(len(hdr))
+	if t.VarLen {
+		return t, nil
+	}
+
+	//grow as the data arrives, rather than trusting the length up front
+	dataStart := len(r.buf)
+	b := bytes.NewBuffer(r.buf)
+	_, err = io.CopyN(b, r.R, dataLen)
+	r.buf = b.Bytes()
+	if err == io.EOF {
+		return t, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return t, err
+	}
+	t.Data = r.buf[dataStart:]
+	return t, nil
+}
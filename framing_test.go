@@ -0,0 +1,113 @@
+package gosmsg
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func readAllOpts(t *testing.T, in string, bufSize int, opts ...ReaderOption) ([]string, error) {
+	var msgs []string
+	for _, zeroCopy := range []bool{false, true} {
+		msgs = nil
+		rOpts := opts
+		if zeroCopy {
+			rOpts = append(rOpts[:len(rOpts):len(rOpts)], WithZeroCopy())
+		}
+		r := NewRawSMsgReader(bufio.NewReaderSize(strings.NewReader(in), bufSize), rOpts...)
+		for {
+			smsg, err := r.ReadRawSMsg()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return msgs, err
+			}
+			msgs = append(msgs, string(smsg.Data))
+		}
+	}
+	return msgs, nil
+}
+
+func TestReaderNewline(t *testing.T) {
+	msgs, err := readAllOpts(t, "10015 hello\r\n10015 world\n\r\n\n10012 hi\r", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(msgs, "|") != "10015 hello|10015 world|||10012 hi" {
+		t.Errorf("Got %q", msgs)
+	}
+}
+
+func TestReaderDelimiter(t *testing.T) {
+	msgs, err := readAllOpts(t, "10015 hello\x0010015 wo\nld\r\x00\x0010012 hi", 16, WithDelimiter([]byte{0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(msgs, "|") != "10015 hello|10015 wo\nld\r||10012 hi" {
+		t.Errorf("Got %q", msgs)
+	}
+
+	long := strings.Repeat("x", 100)
+	in := "10015 hello<EOM>1001" + long + "<EOM>10012 hi<EO<EOM>"
+	msgs, err = readAllOpts(t, in, 16, WithDelimiter([]byte("<EOM>")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(msgs, "|") != "10015 hello|1001"+long+"|10012 hi<EO" {
+		t.Errorf("Got %q", msgs)
+	}
+
+	msgs, err = readAllOpts(t, "10015 hello\n", 16, WithDelimiter([]byte{0}), WithDelimiter(nil))
+	if err != nil || len(msgs) != 1 || msgs[0] != "10015 hello" {
+		t.Errorf("Got %q %v", msgs, err)
+	}
+}
+
+func TestReaderTerminated(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	in := "9019 00015 hello9001 00000 00000 00000 \n" +
+		"0001100 " + long + "00000 " +
+		"00000 "
+	msgs, err := readAllOpts(t, in, 16, WithTerminatorFraming())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{
+		"9019 00015 hello9001 00000 00000 00000 ",
+		"0001100 " + long + "00000 ",
+		"00000 ",
+	}
+	if strings.Join(msgs, "|") != strings.Join(exp, "|") {
+		t.Errorf("Got %q", msgs)
+	}
+
+	errTests := []struct {
+		in  string
+		err error
+	}{
+		{"00015 hello", io.ErrUnexpectedEOF},
+		{"9019 00000 ", io.ErrUnexpectedEOF},
+		{"00015 hel", io.ErrUnexpectedEOF},
+		{"00015 hello000", io.ErrUnexpectedEOF},
+		{"000G5 hello00000 ", strconv.ErrSyntax},
+		{"0001-5 hello00000 ", strconv.ErrRange},
+		{"0001 hello00000 ", ErrVarLenPrimitive},
+	}
+	for _, test := range errTests {
+		_, err := readAllOpts(t, test.in, 16, WithTerminatorFraming())
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: Got %v expected %v", test.in, err, test.err)
+		}
+	}
+
+	r := NewRawSMsgReader(strings.NewReader("000G5 hello00000 00000 "), WithTerminatorFraming())
+	_, err1 := r.ReadRawSMsg()
+	_, err2 := r.ReadRawSMsg()
+	var perr *ParseError
+	if !errors.As(err1, &perr) || perr.Tag != -1 || perr.Offset != 0 || err2 != err1 {
+		t.Errorf("Got %v, %v", err1, err2)
+	}
+}
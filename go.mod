@@ -1,3 +1,5 @@
 module github.com/noselasd/gosmsg
 
-go 1.16
+go 1.23
+
+require github.com/klauspost/compress v1.18.0
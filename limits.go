@@ -0,0 +1,57 @@
+package gosmsg
+
+import (
+	"fmt"
+)
+
+//Limits restricts the work done when iterating hostile or corrupt input
+type Limits struct {
+	//maximum nesting depth of constructors, 0 for no limit
+	MaxDepth int
+	//maximum number of tags, including subtags, 0 for no limit
+	MaxTags int
+}
+
+//A LimitExceededError is returned by Iter.NextTag when a limit
+//of an Iter created with TagsWithLimits is exceeded, and wrapped by
+//the *ValidationError of ValidateWithLimits
+type LimitExceededError struct {
+	//the exceeded limit, "MaxDepth" or "MaxTags"
+	Limit string
+	Max   int
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("gosmsg: %s limit of %d exceeded", e.Limit, e.Max)
+}
+
+//TagsWithLimits is like Tags, but the returned iterator fails with a
+//*LimitExceededError when l is exceeded.
+//Iterators returned by SubTags of the iterated tags share the limits,
+//the number of tags is counted across all of them.
+func (s *RawSMsg) TagsWithLimits(l Limits) Iter {
+	return Iter{data: s.Data, st: &iterState{Limits: l}}
+}
+
+func (i *Iter) checkLimits(t *Tag) error {
+	t.st = i.st
+	t.depth = i.depth + i.varDepth
+
+	i.st.tags++
+	if i.st.MaxTags > 0 && i.st.tags > i.st.MaxTags {
+		return &LimitExceededError{"MaxTags", i.st.MaxTags}
+	}
+
+	if t.Constructor {
+		if i.st.MaxDepth > 0 && t.depth+1 > i.st.MaxDepth {
+			return &LimitExceededError{"MaxDepth", i.st.MaxDepth}
+		}
+		if t.VarLen {
+			i.varDepth++
+		}
+	} else if t.Tag == 0 && len(t.Data) == 0 && i.varDepth > 0 {
+		i.varDepth--
+	}
+
+	return nil
+}
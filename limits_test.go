@@ -0,0 +1,59 @@
+package gosmsg
+
+import (
+	"errors"
+	"testing"
+)
+
+func countTags(r RawSMsg, l Limits) (int, error) {
+	n := 0
+	it := r.TagsWithLimits(l)
+	for _, err := range it.AllRecursive() {
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func TestLimits(t *testing.T) {
+	// depth: 9019 opens 1, 9222 opens 2
+	r := RawSMsg{[]byte("9019 922211 12345 Hello00101 800000 9001 00101 800000 ")}
+
+	tests := []struct {
+		l     Limits
+		n     int
+		limit string
+	}{
+		{Limits{}, 8, ""},
+		{Limits{MaxDepth: 2, MaxTags: 8}, 8, ""},
+		{Limits{MaxDepth: 1}, 1, "MaxDepth"},
+		{Limits{MaxTags: 7}, 7, "MaxTags"},
+		{Limits{MaxTags: 2}, 2, "MaxTags"},
+	}
+
+	for _, test := range tests {
+		n, err := countTags(r, test.l)
+		if n != test.n {
+			t.Errorf("%+v: Got %d tags expected %d", test.l, n, test.n)
+		}
+
+		var lerr *LimitExceededError
+		if test.limit == "" {
+			if err != nil {
+				t.Errorf("%+v: %v", test.l, err)
+			}
+		} else if !errors.As(err, &lerr) || lerr.Limit != test.limit {
+			t.Errorf("%+v: Got %v expected %s", test.l, err, test.limit)
+		}
+	}
+
+	deep := RawSMsg{[]byte("9001 9001 9001 9001 00000 00000 00000 00000 ")}
+	if _, err := countTags(deep, Limits{MaxDepth: 3}); err == nil {
+		t.Error("expected error")
+	}
+	if n, err := countTags(deep, Limits{MaxDepth: 4}); err != nil || n != 8 {
+		t.Errorf("Got %d %v", n, err)
+	}
+}
@@ -0,0 +1,135 @@
+package gosmsg
+
+import (
+	"bytes"
+	"errors"
+)
+
+//ErrMessageTooLong is returned by RawSMsgParser.Write when a message
+//exceeds MaxLen
+var ErrMessageTooLong = errors.New("gosmsg: message too long")
+
+//RawSMsgParser is a push based counterpart of RawSMsgReader.
+//Chunks of a newline separated stream, e.g. as received from a socket,
+//are added with Write and complete RawSMsgs are retrieved with Next.
+//Messages may be split across any number of chunks.
+//The zero value is ready to use.
+type RawSMsgParser struct {
+	//maximum length of a message, excluding the newline, 0 for no limit
+	MaxLen int
+
+	buf []byte
+	//length of the incomplete message at the end of buf
+	tail int
+	//start of the unconsumed data in buf
+	off int
+	//buf[off:scan] is known not to contain a newline
+	scan int
+	//skipping the rest of a too long message
+	discard bool
+}
+
+//Write adds chunk to the data to be parsed. The whole chunk is always
+//consumed.
+//If a message, including the part of it from earlier chunks, exceeds
+//MaxLen, the message is discarded up to and including its newline and
+//ErrMessageTooLong is returned. Messages in chunk before and after the
+//discarded one are kept.
+func (p *RawSMsgParser) Write(chunk []byte) (int, error) {
+	if p.off > 0 {
+		n := copy(p.buf, p.buf[p.off:])
+		p.buf = p.buf[:n]
+		p.scan -= p.off
+		p.off = 0
+	}
+
+	var err error
+	for rest := chunk; len(rest) > 0; {
+		idx := bytes.IndexByte(rest, '\n')
+		if p.discard {
+			if idx == -1 {
+				break
+			}
+			p.discard = false
+			rest = rest[idx+1:]
+			continue
+		}
+
+		msgLen := idx
+		if idx == -1 {
+			msgLen = len(rest)
+		}
+		if p.MaxLen > 0 && p.tail+msgLen > p.MaxLen {
+			//drop the part of the message from earlier chunks
+			p.buf = p.buf[:len(p.buf)-p.tail]
+			p.scan = min(p.scan, len(p.buf))
+			p.tail = 0
+			p.discard = true
+			err = ErrMessageTooLong
+			continue
+		}
+
+		if idx == -1 {
+			p.buf = append(p.buf, rest...)
+			p.tail += len(rest)
+			break
+		}
+		p.buf = append(p.buf, rest[:idx+1]...)
+		p.tail = 0
+		rest = rest[idx+1:]
+	}
+	return len(chunk), err
+}
+
+//Next returns the next complete RawSMsg, or false if more data is needed.
+//The returned RawSMsg could be empty if an empty line is encountered.
+//The data of the returned RawSMsg is not modified by further calls.
+func (p *RawSMsgParser) Next() (RawSMsg, bool) {
+	idx := bytes.IndexByte(p.buf[p.scan:], '\n')
+	if idx == -1 {
+		p.scan = len(p.buf)
+		return RawSMsg{}, false
+	}
+
+	end := p.scan + idx
+	l := p.buf[p.off:end]
+	if len(l) > 0 && l[len(l)-1] == '\r' {
+		l = l[:len(l)-1]
+	}
+
+	p.off = end + 1
+	p.scan = p.off
+	return RawSMsg{append([]byte{}, l...)}, true
+}
+
+//Buffered returns the number of bytes of incomplete messages
+//and messages not yet returned by Next
+func (p *RawSMsgParser) Buffered() int {
+	return len(p.buf) - p.off
+}
+
+//End returns the remaining messages when the stream is ended, one
+//per call, including a final message not followed by a newline.
+//Like RawSMsgReader, a \r ending the final message is removed.
+//Call it until it returns false, which happens when there is no
+//remaining data.
+func (p *RawSMsgParser) End() (RawSMsg, bool) {
+	if p.Buffered() == 0 {
+		p.discard = false
+		return RawSMsg{}, false
+	}
+
+	if msg, ok := p.Next(); ok {
+		return msg, true
+	}
+
+	l := append([]byte{}, p.buf[p.off:]...)
+	if l[len(l)-1] == '\r' {
+		l = l[:len(l)-1]
+	}
+	p.buf = p.buf[:0]
+	p.off = 0
+	p.scan = 0
+	p.tail = 0
+	return RawSMsg{l}, true
+}
@@ -0,0 +1,163 @@
+package gosmsg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParser(t *testing.T) {
+	stream := "10015 hello\r\n10015 world\n\n10012 hi"
+	exp := []string{"10015 hello", "10015 world", ""}
+
+	for chunkLen := 1; chunkLen <= len(stream); chunkLen++ {
+		var p RawSMsgParser
+		var got []string
+
+		for i := 0; i < len(stream); i += chunkLen {
+			end := i + chunkLen
+			if end > len(stream) {
+				end = len(stream)
+			}
+			p.Write([]byte(stream[i:end]))
+			for {
+				msg, ok := p.Next()
+				if !ok {
+					break
+				}
+				got = append(got, string(msg.Data))
+			}
+		}
+
+		if len(got) != len(exp) {
+			t.Fatalf("chunk %d: Got %q expected %q", chunkLen, got, exp)
+		}
+		for i := range exp {
+			if got[i] != exp[i] {
+				t.Errorf("chunk %d: Got %q expected %q", chunkLen, got, exp)
+			}
+		}
+
+		if p.Buffered() != 8 {
+			t.Errorf("chunk %d: Got %d buffered", chunkLen, p.Buffered())
+		}
+		msg, ok := p.End()
+		if !ok || string(msg.Data) != "10012 hi" {
+			t.Errorf("chunk %d: Got %q", chunkLen, msg.Data)
+		}
+		if _, ok = p.End(); ok {
+			t.Error("expected no remaining data")
+		}
+	}
+}
+
+func TestParserNoAlias(t *testing.T) {
+	var p RawSMsgParser
+
+	p.Write([]byte("10015 hello\n100"))
+	msg, _ := p.Next()
+	p.Write([]byte("15 world\n"))
+	p.Next()
+	if string(msg.Data) != "10015 hello" {
+		t.Errorf("Got %q", msg.Data)
+	}
+}
+
+func TestParserEnd(t *testing.T) {
+	var p RawSMsgParser
+
+	p.Write([]byte("a\nb\r\nc\r"))
+	var got []string
+	for {
+		msg, ok := p.End()
+		if !ok {
+			break
+		}
+		got = append(got, string(msg.Data))
+	}
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("Got %q", got)
+	}
+	if p.Buffered() != 0 {
+		t.Errorf("Got %d buffered", p.Buffered())
+	}
+}
+
+func TestParserMaxLen(t *testing.T) {
+	p := RawSMsgParser{MaxLen: 5}
+
+	if _, err := p.Write([]byte("12345\n123")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Write([]byte("45")); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := p.Write([]byte("6")); err != ErrMessageTooLong || n != 1 {
+		t.Errorf("Got %d %v", n, err)
+	}
+	if _, err := p.Write([]byte("7\n1234567\n")); err != ErrMessageTooLong {
+		t.Errorf("Got %v", err)
+	}
+	if _, err := p.Write([]byte("\n12")); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for {
+		msg, ok := p.End()
+		if !ok {
+			break
+		}
+		got = append(got, string(msg.Data))
+	}
+	if len(got) != 3 || got[0] != "12345" || got[1] != "" || got[2] != "12" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestParserMaxLenSpanning(t *testing.T) {
+	p := RawSMsgParser{MaxLen: 20}
+
+	var got []string
+	next := func() {
+		for {
+			msg, ok := p.Next()
+			if !ok {
+				break
+			}
+			got = append(got, string(msg.Data))
+		}
+	}
+
+	if _, err := p.Write([]byte("0001 abc")); err != nil {
+		t.Fatal(err)
+	}
+	next()
+	long := "d\n00012 ab\n" + strings.Repeat("X", 30) + "\n"
+	if _, err := p.Write([]byte(long)); err != ErrMessageTooLong {
+		t.Errorf("Got %v", err)
+	}
+	next()
+	if _, err := p.Write([]byte("0001 efg")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Write([]byte(strings.Repeat("Y", 20))); err != ErrMessageTooLong {
+		t.Errorf("Got %v", err)
+	}
+	if _, err := p.Write([]byte("Y\n00012 cd\n")); err != nil {
+		t.Fatal(err)
+	}
+	next()
+
+	exp := []string{"0001 abcd", "00012 ab", "00012 cd"}
+	if len(got) != len(exp) {
+		t.Fatalf("Got %q expected %q", got, exp)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Errorf("Got %q expected %q", got, exp)
+		}
+	}
+	if p.Buffered() != 0 {
+		t.Errorf("Got %d buffered", p.Buffered())
+	}
+}
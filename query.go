@@ -0,0 +1,138 @@
+package gosmsg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//PathWildcard matches any tag in a Path
+const PathWildcard uint16 = 0xFFFF
+
+//A Path of tag numbers navigating the constructor hierarchy of an SMsg.
+//Each element is a tag number at the corresponding nesting level, or
+//PathWildcard.
+type Path []uint16
+
+//ParsePath parses a path of hex tag numbers separated by '/',
+//e.g. "1019/1222/1234". An element of "*" matches any tag.
+func ParsePath(s string) (Path, error) {
+	s = strings.TrimPrefix(s, "/")
+	if s == "" {
+		return nil, errors.New("gosmsg: empty path")
+	}
+
+	var p Path
+	for _, e := range strings.Split(s, "/") {
+		if e == "*" {
+			p = append(p, PathWildcard)
+			continue
+		}
+		tag, err := strconv.ParseUint(e, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("gosmsg: invalid path element %q: %w", e, err)
+		} else if uint16(tag)&gConstructor != 0 {
+			return nil, fmt.Errorf("gosmsg: invalid path element %q: %w", e, strconv.ErrRange)
+		}
+		p = append(p, uint16(tag))
+	}
+	return p, nil
+}
+
+func (p Path) String() string {
+	elems := make([]string, len(p))
+	for i, tag := range p {
+		if tag == PathWildcard {
+			elems[i] = "*"
+		} else {
+			elems[i] = string(uint16ToHex(tag))
+		}
+	}
+	return strings.Join(elems, "/")
+}
+
+//Query returns all tags matching path, see ParsePath and QueryPath
+func (s *RawSMsg) Query(path string) ([]Tag, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.QueryPath(p)
+}
+
+//QueryPath returns all tags matching p in the order they occur.
+//The first element of p is matched against the top level tags, each
+//following element against the subtags of the constructors matched by
+//the previous element. For variable length constructors, the returned
+//Data is limited to the content up to the terminating 0x0000 tag.
+func (s *RawSMsg) QueryPath(p Path) ([]Tag, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+	return queryTags(s.Data, 0, p, nil)
+}
+
+//queryTags matches p against the tags in data, which starts at
+//offset base of the SMsg
+func queryTags(data []byte, base int, p Path, found []Tag) ([]Tag, error) {
+	tags, err := childTags(data, base)
+	if err != nil {
+		return found, err
+	}
+
+	for _, t := range tags {
+		if p[0] != PathWildcard && p[0] != t.Tag {
+			continue
+		}
+		if len(p) == 1 {
+			found = append(found, t)
+		} else if t.Constructor {
+			if found, err = queryTags(t.Data, t.dataOff, p[1:], found); err != nil {
+				return found, err
+			}
+		}
+	}
+	return found, nil
+}
+
+//childTags returns the tags directly contained in data, i.e. excluding
+//the content of variable length constructors. The Data of variable
+//length constructors is trimmed to their content, excluding the
+//terminating 0x0000 tag. An unterminated variable length constructor
+//extends to the end of data. base is the offset of data within the SMsg,
+//used for the offsets of errors.
+func childTags(data []byte, base int) ([]Tag, error) {
+	var tags []Tag
+	//number of open variable length constructors
+	depth := 0
+	i := Iter{data: data, off: base}
+	for {
+		rest := i.data
+		t, err := i.NextTag()
+		if err == io.EOF {
+			return tags, nil
+		} else if err != nil {
+			return tags, err
+		}
+
+		if depth > 0 {
+			if t.Tag == 0 && !t.Constructor && len(t.Data) == 0 {
+				depth--
+				if depth == 0 {
+					vt := &tags[len(tags)-1]
+					vt.Data = vt.Data[:len(vt.Data)-len(rest)]
+				}
+			} else if t.Constructor && t.VarLen {
+				depth++
+			}
+			continue
+		}
+
+		tags = append(tags, t)
+		if t.Constructor && t.VarLen {
+			depth++
+		}
+	}
+}
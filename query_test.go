@@ -0,0 +1,70 @@
+package gosmsg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	p, err := ParsePath("/1019/*/1234")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(p) != 3 || p[0] != 0x1019 || p[1] != PathWildcard || p[2] != 0x1234 {
+		t.Errorf("Got %v", p)
+	} else if p.String() != "1019/*/1234" {
+		t.Errorf("Got %s", p)
+	}
+
+	for _, bad := range []string{"", "/", "1019//1234", "10G9", "8001", "10190"} {
+		if _, err := ParsePath(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	r := RawSMsg{[]byte("9019 922211 12345 Hello00101 800000 9019 9222 12345 World00000 00000 00103 end")}
+
+	tests := []struct {
+		path string
+		exp  []string
+	}{
+		{"1019/1222/1234", []string{"Hello", "World"}},
+		{"1019/*/1234", []string{"Hello", "World"}},
+		{"1019/0010", []string{"8"}},
+		{"0010", []string{"end"}},
+		{"1019/1222", []string{"12345 Hello", "12345 World"}},
+		{"1019", []string{"922211 12345 Hello00101 8", "9222 12345 World00000 "}},
+		{"1234", nil},
+		{"0010/1234", nil},
+	}
+
+	for _, test := range tests {
+		tags, err := r.Query(test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		if len(tags) != len(test.exp) {
+			t.Errorf("%s: Got %v expected %q", test.path, tags, test.exp)
+			continue
+		}
+		for i := range tags {
+			if string(tags[i].Data) != test.exp[i] {
+				t.Errorf("%s: Got %q expected %q", test.path, tags[i].Data, test.exp[i])
+			}
+		}
+	}
+
+	bad := RawSMsg{[]byte("9019 922211 12345 HelloH001 x")}
+	if _, err := bad.Query("1019/1222"); err == nil {
+		t.Error("expected error")
+	}
+
+	//offsets of errors in nested constructors are within the SMsg
+	bad = RawSMsg{[]byte("901912 00101 8100G2 hi")}
+	var perr *ParseError
+	if _, err := bad.Query("1019/1"); !errors.As(err, &perr) || perr.Offset != 14 {
+		t.Errorf("Got %v", err)
+	}
+}
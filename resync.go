@@ -0,0 +1,189 @@
+package gosmsg
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"io"
+	"slices"
+)
+
+//A SkippedRange describes data skipped while resynchronizing
+//after malformed data
+type SkippedRange struct {
+	//byte offset of the skipped data, within the SMsg for an Iter
+	//and within the stream for a RawSMsgReader
+	Offset int64
+	//number of bytes skipped
+	Len int
+	//the error that caused the data to be skipped, offsets within it
+	//are relative to the SMsg
+	Err error
+}
+
+//EnableResync turns on error recovery. Instead of returning a
+//*ParseError for a malformed tag, NextTag skips forward to the next
+//plausible tag and continues from there. The skipped data is reported
+//by Skipped.
+//A plausible tag is one that parses, including its length, where
+//tags without a length must have the constructor bit set.
+//Iterators returned by SubTags of the iterated tags resync as well.
+func (i *Iter) EnableResync() {
+	if i.st == nil {
+		i.st = &iterState{}
+	}
+	i.st.resync = true
+}
+
+//Skipped returns the data skipped since EnableResync was called,
+//including data skipped by iterators from SubTags
+func (i *Iter) Skipped() []SkippedRange {
+	if i.st == nil {
+		return nil
+	}
+	return i.st.skipped
+}
+
+func (i *Iter) resync(start []byte, tagOff int, err error) (Tag, error) {
+	p := 1
+	for ; p < len(start); p++ {
+		if plausibleTag(start[p:]) {
+			break
+		}
+	}
+
+	i.st.skipped = append(i.st.skipped, SkippedRange{int64(tagOff), p, err})
+	i.data = start[p:]
+	i.off = tagOff + p
+	if len(i.data) == 0 {
+		return Tag{}, io.EOF
+	}
+	return i.NextTag()
+}
+
+func plausibleTag(data []byte) bool {
+	i := Iter{data: data}
+	t, err := i.nextTag()
+	return err == nil && (!t.VarLen || t.Constructor)
+}
+
+//checkTag checks what Validate checks for t, beyond the tag syntax:
+//the content of fixed length constructors and newlines.
+//raw is the data of t including its header, found at offset off of the
+//SMsg, depth is the nesting depth of t.
+func checkTag(t *Tag, raw []byte, off int, depth int) error {
+	if idx := bytes.IndexAny(raw, "\r\n"); idx != -1 {
+		return &ValidationError{off + idx, ErrEmbeddedNewline}
+	} else if t.VarLen && !t.Constructor {
+		return &ValidationError{off, ErrVarLenPrimitive}
+	} else if t.Constructor && !t.VarLen {
+		dataOff := off + len(raw) - len(t.Data)
+		st := &iterState{Limits: Limits{MaxDepth: gValidateMaxDepth}}
+		return validateTags(t.Data, dataOff, depth+1, st)
+	}
+	return nil
+}
+
+//salvageTags returns the tags of data that pass Validate, with the
+//malformed data in between removed and reported with offsets within
+//data. The tags of unterminated variable length constructors are
+//removed as well, leaving their content in place.
+func salvageTags(data []byte) ([]byte, []SkippedRange) {
+	var out []byte
+	var skipped []SkippedRange
+	//open variable length constructors
+	type varLenTag struct {
+		outOff, off, len int
+	}
+	var open []varLenTag
+
+	for off := 0; off < len(data); {
+		i := Iter{data: data[off:], off: off}
+		t, err := i.NextTag()
+		n := len(data) - off - len(i.data)
+		if err == io.EOF {
+			//less than a tag left
+			err = &ValidationError{off, io.ErrShortBuffer}
+		} else if err == nil {
+			err = checkTag(&t, data[off:off+n], off, len(open))
+		}
+
+		if err != nil {
+			p := off + 1
+			for p < len(data) && !plausibleTag(data[p:]) {
+				p++
+			}
+			skipped = append(skipped, SkippedRange{int64(off), p - off, err})
+			off = p
+			continue
+		}
+
+		if t.VarLen {
+			open = append(open, varLenTag{len(out), off, n})
+		} else if t.Tag == 0 && !t.Constructor && len(t.Data) == 0 && len(open) > 0 {
+			open = open[:len(open)-1]
+		}
+		out = append(out, data[off:off+n]...)
+		off += n
+	}
+
+	for k := len(open) - 1; k >= 0; k-- {
+		v := open[k]
+		out = append(out[:v.outOff], out[v.outOff+v.len:]...)
+		skipped = append(skipped, SkippedRange{int64(v.off), v.len, &ValidationError{v.off, ErrUnterminated}})
+	}
+	slices.SortFunc(skipped, func(a, b SkippedRange) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+	return out, skipped
+}
+
+//skipMalformed discards the start of a malformed tag from r.R up to the
+//next plausible tag and returns the number of bytes discarded
+func (r *RawSMsgReader) skipMalformed() (int, error) {
+	n := 0
+	for {
+		if _, err := r.R.Discard(1); err != nil {
+			return n, err
+		}
+		n++
+
+		ok, err := r.plausibleTagAhead()
+		if err != nil {
+			return n, err
+		} else if ok {
+			return n, nil
+		}
+	}
+}
+
+//plausibleTagAhead reports whether r.R continues with a plausible tag,
+//see plausibleTag. Only as much data as needed is read ahead, a tag
+//with more data than fits in the buffer of r.R is plausible if its
+//header is.
+func (r *RawSMsgReader) plausibleTagAhead() (bool, error) {
+	ahead, _ := r.R.Peek(r.R.Buffered())
+	for {
+		i := Iter{data: ahead}
+		t, err := i.nextTag()
+		if err != io.EOF && err != io.ErrShortBuffer {
+			return err == nil && (!t.VarLen || t.Constructor), nil
+		}
+
+		more, perr := r.R.Peek(len(ahead) + 1)
+		if perr == bufio.ErrBufferFull {
+			//the buffer holds more than a header
+			return true, nil
+		} else if perr == io.EOF {
+			return false, nil
+		} else if perr != nil {
+			return false, perr
+		}
+		ahead = more
+	}
+}
+
+//Skipped returns the data skipped when WithResync is used
+func (r *RawSMsgReader) Skipped() []SkippedRange {
+	return r.skipped
+}
@@ -0,0 +1,192 @@
+package gosmsg
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestIterResync(t *testing.T) {
+	r := RawSMsg{[]byte("00102 hi1001X junk00103 abc90198 0001-1 x00015 Hello")}
+
+	it := r.Tags()
+	it.EnableResync()
+	var got []string
+	for tag, err := range it.AllRecursive() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, tag.String())
+	}
+
+	exp := []string{
+		"Tag: 0x0010 C:false Data:hi",
+		"Tag: 0x0010 C:false Data:abc",
+		"Tag: 0x1019 C:true Data:0001-1 x",
+		"Tag: 0x0001 C:false Data:Hello",
+	}
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Errorf("Got\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(exp, "\n"))
+	}
+
+	skipped := it.Skipped()
+	if len(skipped) != 2 {
+		t.Fatalf("Got %v", skipped)
+	}
+	if skipped[0].Offset != 8 || skipped[0].Len != 10 || !errors.Is(skipped[0].Err, strconv.ErrSyntax) {
+		t.Errorf("Got %+v", skipped[0])
+	}
+	if skipped[1].Offset != 33 || skipped[1].Len != 8 || !errors.Is(skipped[1].Err, strconv.ErrRange) {
+		t.Errorf("Got %+v", skipped[1])
+	}
+
+	r = RawSMsg{[]byte("00102 hi0010 junk")}
+	it = r.Tags()
+	it.EnableResync()
+	n := 0
+	for _, err := range it.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != 2 || len(it.Skipped()) != 1 || it.Skipped()[0].Offset != 13 || it.Skipped()[0].Len != 4 {
+		t.Errorf("Got %d %+v", n, it.Skipped())
+	}
+}
+
+func TestReaderResync(t *testing.T) {
+	in := "10015 hello\n10015 he\n\n10015 world\n9019 0001\n00011 aXX00011 b9019 00011 c"
+
+	r := NewRawSMsgReader(strings.NewReader(in), WithResync())
+	var got []string
+	for {
+		smsg, err := r.ReadRawSMsg()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if err := smsg.Validate(); err != nil {
+			t.Errorf("%q: %v", smsg.Data, err)
+		}
+		got = append(got, string(smsg.Data))
+	}
+
+	if strings.Join(got, "|") != "10015 hello||10015 world|00011 a00011 b00011 c" {
+		t.Errorf("Got %q", got)
+	}
+	exp := []SkippedRange{{12, 8, nil}, {34, 5, ErrUnterminated}, {39, 4, nil}, {51, 2, nil}, {60, 5, ErrUnterminated}}
+	skipped := r.Skipped()
+	if len(skipped) != len(exp) {
+		t.Fatalf("Got %+v", skipped)
+	}
+	for i, e := range exp {
+		if skipped[i].Offset != e.Offset || skipped[i].Len != e.Len || (e.Err != nil && !errors.Is(skipped[i].Err, e.Err)) {
+			t.Errorf("%d: Got %+v expected %+v", i, skipped[i], e)
+		}
+	}
+}
+
+func TestReaderResyncTerminated(t *testing.T) {
+	tests := []struct {
+		in      string
+		exp     []string
+		skipped []SkippedRange
+	}{
+		{
+			"00011 a00000 XX00011 b00000 00011 c00000 ",
+			[]string{"00011 a00000 ", "00011 b00000 ", "00011 c00000 "},
+			[]SkippedRange{{13, 2, strconv.ErrSyntax}},
+		},
+		{
+			"00011 a0010 00011 b00000 ",
+			[]string{"00011 a00011 b00000 "},
+			[]SkippedRange{{7, 5, ErrVarLenPrimitive}},
+		},
+		{
+			"80019 00011 aXX00000 9019 00011 dXX00000 00000 ",
+			[]string{"00000 ", "9019 00011 d00000 00000 "},
+			[]SkippedRange{{0, 15, io.ErrShortBuffer}, {33, 2, strconv.ErrSyntax}},
+		},
+		{
+			"00011 a00000 0G011 b00000 00011 c00000 ",
+			[]string{"00011 a00000 ", "b00000 00011 c00000 "},
+			[]SkippedRange{{13, 6, strconv.ErrSyntax}},
+		},
+		{
+			"00011 a00000 XXXX",
+			[]string{"00011 a00000 "},
+			[]SkippedRange{{13, 4, strconv.ErrSyntax}},
+		},
+	}
+
+	for _, test := range tests {
+		for _, bufSize := range []int{16, 4096} {
+			r := NewRawSMsgReader(bufio.NewReaderSize(strings.NewReader(test.in), bufSize),
+				WithTerminatorFraming(), WithResync())
+			var got []string
+			for {
+				smsg, err := r.ReadRawSMsg()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("%q: %v", test.in, err)
+				}
+				got = append(got, string(smsg.Data))
+			}
+
+			if strings.Join(got, "|") != strings.Join(test.exp, "|") {
+				t.Errorf("%q: Got %q", test.in, got)
+			}
+			skipped := r.Skipped()
+			if len(skipped) != len(test.skipped) {
+				t.Errorf("%q: Got %+v", test.in, skipped)
+				continue
+			}
+			for i, e := range test.skipped {
+				if skipped[i].Offset != e.Offset || skipped[i].Len != e.Len || !errors.Is(skipped[i].Err, e.Err) {
+					t.Errorf("%q: Got %+v expected %+v", test.in, skipped[i], e)
+				}
+			}
+		}
+	}
+
+	//the stream ends within a message
+	r := NewRawSMsgReader(strings.NewReader("00011 aXX"), WithTerminatorFraming(), WithResync())
+	if _, err := r.ReadRawSMsg(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Got %v", err)
+	}
+}
+
+func TestReaderResyncLongTag(t *testing.T) {
+	//a tag with more data than fits in the buffer is still found
+	long := strings.Repeat("x", 100)
+	in := "XX0001100 " + long + "00000 "
+	r := NewRawSMsgReader(bufio.NewReaderSize(strings.NewReader(in), 16), WithTerminatorFraming(), WithResync())
+	smsg, err := r.ReadRawSMsg()
+	if err != nil || string(smsg.Data) != "0001100 "+long+"00000 " {
+		t.Errorf("Got %q %v", smsg.Data, err)
+	}
+	if skipped := r.Skipped(); len(skipped) != 1 || skipped[0].Offset != 0 || skipped[0].Len != 2 {
+		t.Errorf("Got %+v", skipped)
+	}
+}
+
+func TestIterResyncLongJunk(t *testing.T) {
+	//a long run without spaces must not make resync quadratic
+	r := RawSMsg{[]byte("0001X" + strings.Repeat("1", 1<<20) + "00012 hi")}
+
+	it := r.Tags()
+	it.EnableResync()
+	tag, err := it.NextTag()
+	if err != nil || tag.Tag != 1 || string(tag.Data) != "hi" {
+		t.Errorf("Got %s %v", &tag, err)
+	}
+	if skipped := it.Skipped(); len(skipped) != 1 || skipped[0].Len != 5+1<<20 {
+		t.Errorf("Got %+v", skipped)
+	}
+}
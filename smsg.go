@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"iter"
 	"strconv"
 )
 
@@ -12,9 +13,26 @@ type RawSMsg struct {
 	Data []byte
 }
 
+//Clone returns a deep copy of s, safe to retain after the
+//data of s is reused
+func (s *RawSMsg) Clone() RawSMsg {
+	if s.Data == nil {
+		return RawSMsg{}
+	}
+	return RawSMsg{append([]byte{}, s.Data...)}
+}
+
+//Equal reports whether s and o contain the same bytes
+func (s *RawSMsg) Equal(o *RawSMsg) bool {
+	return bytes.Equal(s.Data, o.Data)
+}
+
 const gConstructor uint16 = 0x8000
 const gVariableLen = -2
 
+//maximum number of digits of a tag length, enough for any int32
+const gMaxLenDigits = 10
+
 var gHex = [...]byte{'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'A', 'B', 'C', 'D', 'E', 'F'}
 
 //fast implementation
@@ -72,8 +90,32 @@ func (s *RawSMsg) AddSafe(tag uint16, data []byte) {
 }
 
 //An Iter used to iterate through Tags
+//
+//By default the Data of the returned Tags aliases the iterated data,
+//and is only valid as long as that is not modified or reused.
 type Iter struct {
+	//when set, the Data of returned Tags is a copy owned by the caller.
+	//Iterators from SubTags of such Tags alias the copy.
+	CopyData bool
+
 	data []byte
+	//offset of data within the SMsg
+	off int
+	st  *iterState
+	//nesting depth of data
+	depth int
+	//number of open variable length constructors
+	varDepth int
+}
+
+//iterState is shared between an Iter and the iterators
+//returned by SubTags of its tags
+type iterState struct {
+	Limits
+	//number of tags iterated
+	tags    int
+	resync  bool
+	skipped []SkippedRange
 }
 
 //A Tag of an SMsg
@@ -82,6 +124,18 @@ type Tag struct {
 	Constructor bool
 	VarLen      bool
 	Data        []byte
+	dataOff     int
+	st          *iterState
+	depth       int
+}
+
+//Copy returns a copy of t with its own copy of Data
+func (t *Tag) Copy() Tag {
+	c := *t
+	if t.Data != nil {
+		c.Data = append([]byte{}, t.Data...)
+	}
+	return c
 }
 
 func (t *Tag) String() string {
@@ -90,16 +144,83 @@ func (t *Tag) String() string {
 
 //Tags returns an iterator used to iterate all the tags in the SMsg
 func (s *RawSMsg) Tags() Iter {
-	return Iter{s.Data}
+	return Iter{data: s.Data}
 }
 
 func (t *Tag) SubTags() Iter {
-	return Iter{t.Data}
+	return Iter{data: t.Data, off: t.dataOff, st: t.st, depth: t.depth + 1}
+}
+
+//A ParseError describes a malformed tag found by Iter.NextTag
+type ParseError struct {
+	//byte offset of the malformed tag within the SMsg
+	Offset int
+	//tag number, or -1 if the tag number itself is malformed
+	Tag int
+	//a copy of at most 32 bytes starting at the malformed tag.
+	//Bytes before Offset are not included.
+	Near []byte
+	//the underlying error, e.g. io.ErrShortBuffer or a *strconv.NumError
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	tag := "?"
+	if e.Tag >= 0 {
+		tag = fmt.Sprintf("0x%04X", e.Tag)
+	}
+	return fmt.Sprintf("gosmsg: malformed tag %s at offset %d near %q: %v", tag, e.Offset, e.Near, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+//nearBytes returns a copy of the start of b for ParseError.Near
+func nearBytes(b []byte) []byte {
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	return append([]byte{}, b...)
 }
 
 //NextTag returns the next Tag in the SMsg or an error.
-//io.EOF is returned when there is no more tags to iterate
+//io.EOF is returned when there is no more tags to iterate,
+//malformed tags result in a *ParseError.
 func (i *Iter) NextTag() (t Tag, err error) {
+	start := i.data
+	tagOff := i.off
+	t, err = i.nextTag()
+	i.off += len(start) - len(i.data)
+
+	if err == io.EOF {
+		return t, err
+	} else if err != nil {
+		perr := &ParseError{Offset: tagOff, Tag: -1, Near: nearBytes(start), Err: err}
+		if len(i.data) < len(start) {
+			perr.Tag = int(t.Tag)
+		}
+		if i.st != nil && i.st.resync {
+			return i.resync(start, tagOff, perr)
+		}
+		return t, perr
+	}
+
+	// t.Data is a subslice of start
+	t.dataOff = tagOff + cap(start) - cap(t.Data)
+
+	if i.CopyData {
+		t = t.Copy()
+	}
+
+	if i.st != nil {
+		return t, i.checkLimits(&t)
+	}
+
+	return t, nil
+}
+
+func (i *Iter) nextTag() (t Tag, err error) {
 	if len(i.data) < 4 { //tag
 		return t, io.EOF
 	}
@@ -113,9 +234,21 @@ func (i *Iter) NextTag() (t Tag, err error) {
 	t.Constructor = uint16(tag)&gConstructor != 0
 	t.Tag = uint16(tag) & ^gConstructor
 
+	if len(i.data) == 0 {
+		return t, io.ErrShortBuffer
+	}
+
 	if i.data[0] != ' ' {
-		dataStart := bytes.IndexByte(i.data, ' ')
+		//the length has at most gMaxLenDigits digits, don't scan further
+		lenField := i.data
+		if len(lenField) > gMaxLenDigits+1 {
+			lenField = lenField[:gMaxLenDigits+1]
+		}
+		dataStart := bytes.IndexByte(lenField, ' ')
 		if dataStart == -1 {
+			if len(lenField) > gMaxLenDigits {
+				return t, strconv.ErrRange
+			}
 			return t, io.ErrShortBuffer
 		}
 
@@ -150,39 +283,171 @@ func (i *Iter) NextTag() (t Tag, err error) {
 	return t, nil
 }
 
+//All returns an iterator over the remaining tags.
+//Iteration stops at the end of the data or after the first
+//error, which is yielded together with the partially parsed Tag.
+func (i *Iter) All() iter.Seq2[Tag, error] {
+	return func(yield func(Tag, error) bool) {
+		for {
+			t, err := i.NextTag()
+			if err == io.EOF {
+				return
+			}
+			if !yield(t, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+//AllRecursive is like All, but also walks the subtags of fixed length
+//constructors depth-first, right after the constructor tag itself.
+//Subtags of variable length constructors are already part of the
+//regular tag sequence.
+func (i *Iter) AllRecursive() iter.Seq2[Tag, error] {
+	return func(yield func(Tag, error) bool) {
+		walkTags(i, yield)
+	}
+}
+
+func walkTags(i *Iter, yield func(Tag, error) bool) bool {
+	for {
+		t, err := i.NextTag()
+		if err == io.EOF {
+			return true
+		}
+		if !yield(t, err) || err != nil {
+			return false
+		}
+		if t.Constructor && !t.VarLen {
+			sub := t.SubTags()
+			if !walkTags(&sub, yield) {
+				return false
+			}
+		}
+	}
+}
+
+//AllTags returns an iterator over all the tags in the SMsg, see Iter.All
+func (s *RawSMsg) AllTags() iter.Seq2[Tag, error] {
+	return func(yield func(Tag, error) bool) {
+		i := s.Tags()
+		i.All()(yield)
+	}
+}
+
+//AllTagsRecursive returns an iterator over all the tags in the SMsg
+//including subtags of constructors, see Iter.AllRecursive
+func (s *RawSMsg) AllTagsRecursive() iter.Seq2[Tag, error] {
+	return func(yield func(Tag, error) bool) {
+		i := s.Tags()
+		walkTags(&i, yield)
+	}
+}
+
 //RawSMsgReader is used to read RawSMsgs from a stream.
+//By default messages are separated by newlines, see ReaderOption for
+//other framings and settings.
 type RawSMsgReader struct {
 	//reader to read SMsgs from
-	R         *bufio.Reader
-	lastError error
+	R *bufio.Reader
+	//see WithZeroCopy
+	zeroCopy bool
+	//see WithResync
+	resync  bool
+	skipped []SkippedRange
+	off     int64
+	//separator, nil for newlines
+	delim []byte
+	//records are ended by the 0x0000 terminator tag
+	terminated bool
+	buf        []byte
+	lastError  error
+	//decompressor, see NewRawSMsgReaderAuto
+	closer io.Closer
 }
 
 //NewRawSMsgReader returns a new RawSMsgReader reading from r.
 //r is wrapped in a *bufio.Reader unless it already is a *bufio.Reader
-func NewRawSMsgReader(r io.Reader) RawSMsgReader {
+func NewRawSMsgReader(r io.Reader, opts ...ReaderOption) RawSMsgReader {
 	rr := RawSMsgReader{}
 	if bufR, ok := r.(*bufio.Reader); ok {
 		rr.R = bufR
 	} else {
 		rr.R = bufio.NewReader(r)
 	}
+	for _, opt := range opts {
+		opt(&rr)
+	}
 	return rr
 }
 
 //ReadRawSMsg returns the next RawSmsg or an error.
 //error will be io.EOF when the end is reached
+//With the default newline framing, both \n and \r\n end a message.
 //The returned RawSmsg could be empty if an empty line
 //is encountered.
 func (r *RawSMsgReader) ReadRawSMsg() (RawSMsg, error) {
-	l, err := r.R.ReadBytes('\n')
+	for {
+		off := r.off
+		msg, n, err := r.readRawSMsg()
+		r.off += int64(n)
+		//readTerminated already resyncs
+		if err != nil || !r.resync || r.terminated || msg.Validate() == nil {
+			return msg, err
+		}
+
+		data, skipped := salvageTags(msg.Data)
+		for _, sr := range skipped {
+			sr.Offset += off
+			r.skipped = append(r.skipped, sr)
+		}
+		if len(data) > 0 {
+			return RawSMsg{data}, nil
+		}
+	}
+}
+
+//Close releases the decompressor of a reader returned by
+//NewRawSMsgReaderAuto. The underlying io.Reader is not closed.
+func (r *RawSMsgReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	err := r.closer.Close()
+	r.closer = nil
+	return err
+}
+
+//readRawSMsg reads the next RawSMsg and returns the number of bytes
+//consumed from the stream
+func (r *RawSMsgReader) readRawSMsg() (RawSMsg, int, error) {
+	if r.terminated {
+		l, n, err := r.readTerminated()
+		if r.lastError != nil {
+			return RawSMsg{}, n, r.lastError
+		}
+		r.lastError = err
+		if err != nil {
+			return RawSMsg{}, n, err
+		}
+		return RawSMsg{l}, n, nil
+	}
+
+	l, err := r.readLine()
+	n := len(l)
 	if r.lastError != nil {
-		return RawSMsg{}, r.lastError
+		return RawSMsg{}, n, r.lastError
 	}
 	if len(l) > 0 {
 		err = nil
-		for _, b := range []byte("\r\n") {
-			if len(l) > 0 && l[len(l)-1] == b {
-				l = l[:len(l)-1]
+		if r.delim != nil {
+			l = bytes.TrimSuffix(l, r.delim)
+		} else {
+			for _, b := range []byte("\n\r") {
+				if len(l) > 0 && l[len(l)-1] == b {
+					l = l[:len(l)-1]
+				}
 			}
 		}
 	} else if err == nil {
@@ -190,5 +455,5 @@ func (r *RawSMsgReader) ReadRawSMsg() (RawSMsg, error) {
 	}
 
 	r.lastError = err
-	return RawSMsg{l}, err
+	return RawSMsg{l}, n, err
 }
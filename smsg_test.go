@@ -1,9 +1,13 @@
 package gosmsg
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -130,25 +134,71 @@ func TestParseErr(t *testing.T) {
 	r4 := RawSMsg{[]byte("1001-2 hi ")}
 	i4 := r4.Tags()
 	tag, err = i4.NextTag()
-	if err != strconv.ErrRange {
+	if !errors.Is(err, strconv.ErrRange) {
 		t.Error("expected error")
 	}
 
 	r5 := RawSMsg{[]byte("10014 hi ")}
 	i5 := r5.Tags()
 	tag, err = i5.NextTag()
-	if err != io.ErrShortBuffer {
+	if !errors.Is(err, io.ErrShortBuffer) {
 		t.Error("expected error")
 	}
 
 	r6 := RawSMsg{[]byte("10012hi")}
 	i6 := r6.Tags()
 	tag, err = i6.NextTag()
-	if err != io.ErrShortBuffer {
+	if !errors.Is(err, io.ErrShortBuffer) {
 		t.Error("expected error")
 	}
 }
 
+func TestParseErrLongLength(t *testing.T) {
+	r := RawSMsg{[]byte("000112345678901 hi")}
+	it := r.Tags()
+	if _, err := it.NextTag(); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("Got %v", err)
+	}
+
+	r = RawSMsg{[]byte("00012147483647")}
+	it = r.Tags()
+	if _, err := it.NextTag(); !errors.Is(err, io.ErrShortBuffer) {
+		t.Errorf("Got %v", err)
+	}
+}
+
+func TestParseErrDetails(t *testing.T) {
+	r := RawSMsg{[]byte("9019 922211 12345 Hello00101 8000A5 abc")}
+
+	var perr *ParseError
+	var err error
+	for _, err = range r.AllTagsRecursive() {
+		if err != nil {
+			break
+		}
+	}
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if perr.Offset != 30 || perr.Tag != 0xA || string(perr.Near) != "000A5 abc" ||
+		!errors.Is(err, io.ErrShortBuffer) {
+		t.Errorf("Got %v", perr)
+	}
+	r.Data[30] = 'X'
+	if string(perr.Near) != "000A5 abc" {
+		t.Errorf("Near aliases the iterated data, Got %q", perr.Near)
+	}
+
+	r = RawSMsg{[]byte("901912 00101 8100G2 hi")}
+	tags, err := r.FindAll(0x1, true)
+	if !errors.As(err, &perr) || len(tags) != 0 {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if perr.Offset != 14 || perr.Tag != -1 || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Got %v", perr)
+	}
+}
+
 func TestReader(t *testing.T) {
 	msg := []byte("10015 hello \n10015 hello \n\n")
 	b := bytes.NewBuffer(msg)
@@ -176,3 +226,138 @@ func TestReader(t *testing.T) {
 	t.Logf("%v", smsg)
 
 }
+
+func TestAllTags(t *testing.T) {
+	r := RawSMsg{[]byte("9019 922211 12345 Hello00101 800000 ")}
+	exp := []uint16{0x1019, 0x1222, 0x0010, 0x0000}
+
+	for n := 0; n < 2; n++ {
+		var got []uint16
+		for tag, err := range r.AllTags() {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, tag.Tag)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(exp) {
+			t.Errorf("Got %X expected %X", got, exp)
+		}
+	}
+
+	expRec := []uint16{0x1019, 0x1222, 0x1234, 0x0010, 0x0000}
+	var got []uint16
+	for tag, err := range r.AllTagsRecursive() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, tag.Tag)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expRec) {
+		t.Errorf("Got %X expected %X", got, expRec)
+	}
+
+	got = nil
+	for tag := range r.AllTagsRecursive() {
+		got = append(got, tag.Tag)
+		if tag.Tag == 0x1234 {
+			break
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("Got %X, expected iteration to stop", got)
+	}
+
+	bad := RawSMsg{[]byte("10012 hiH0012 hi")}
+	n := 0
+	for _, err := range bad.AllTags() {
+		n++
+		if n == 2 && err == nil {
+			t.Error("expected error")
+		}
+	}
+	if n != 2 {
+		t.Errorf("Got %d tags, expected 2", n)
+	}
+}
+
+func TestIterCopy(t *testing.T) {
+	r := RawSMsg{[]byte("901911 12345 Hello00101 8")}
+
+	it := r.Tags()
+	tag, _ := it.NextTag()
+	c := tag.Copy()
+	it.CopyData = true
+	owned, _ := it.NextTag()
+
+	for i := range r.Data {
+		r.Data[i] = 'X'
+	}
+
+	if string(tag.Data) == "12345 Hello" {
+		t.Error("expected Tag to alias the iterated data")
+	}
+	if string(c.Data) != "12345 Hello" || c.Tag != 0x1019 || !c.Constructor {
+		t.Errorf("Got %s", &c)
+	}
+	if string(owned.Data) != "8" {
+		t.Errorf("Got %s", &owned)
+	}
+
+	sub := c.SubTags()
+	subTag, err := sub.NextTag()
+	if err != nil || string(subTag.Data) != "Hello" {
+		t.Errorf("Got %s %v", &subTag, err)
+	}
+}
+
+func TestReaderZeroCopy(t *testing.T) {
+	long := strings.Repeat("x", 5000)
+	msg := "10015 hello\n10015 world\n1001" + long + "\n10015 last"
+
+	for _, zeroCopy := range []bool{false, true} {
+		var opts []ReaderOption
+		if zeroCopy {
+			opts = append(opts, WithZeroCopy())
+		}
+		r := NewRawSMsgReader(bufio.NewReaderSize(strings.NewReader(msg), 16), opts...)
+
+		first, err := r.ReadRawSMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []string{"10015 world", "1001" + long, "10015 last"}
+		for _, e := range exp {
+			smsg, err := r.ReadRawSMsg()
+			if err != nil {
+				t.Fatal(err)
+			} else if string(smsg.Data) != e {
+				t.Errorf("ZeroCopy %t: Got %q expected %q", zeroCopy, smsg.Data, e)
+			}
+		}
+		if _, err := r.ReadRawSMsg(); err != io.EOF {
+			t.Fatal(err)
+		}
+
+		if aliased := string(first.Data) != "10015 hello"; aliased != zeroCopy {
+			t.Errorf("ZeroCopy %t: Got %q", zeroCopy, first.Data)
+		}
+	}
+}
+
+func TestCloneEqual(t *testing.T) {
+	r := RawSMsg{[]byte("10015 hello")}
+	c := r.Clone()
+	if !c.Equal(&r) {
+		t.Errorf("Got %q", c.Data)
+	}
+
+	r.Data[0] = '2'
+	if c.Equal(&r) || string(c.Data) != "10015 hello" {
+		t.Errorf("Got %q", c.Data)
+	}
+
+	var empty RawSMsg
+	if c = empty.Clone(); c.Data != nil || !c.Equal(&RawSMsg{[]byte{}}) {
+		t.Errorf("Got %q", c.Data)
+	}
+}
@@ -0,0 +1,180 @@
+//Package smsgindex provides random access to newline separated SMsgs
+//in large files by building an index of the message offsets.
+package smsgindex
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/noselasd/gosmsg"
+)
+
+//An Entry describes a single message in the indexed stream
+type Entry struct {
+	//byte offset of the message
+	Offset int64
+	//length of the message, excluding the newline
+	Length int
+	//record tag, the first tag of the message
+	Tag uint16
+	//data of the key field, see BuildKeyed
+	Key string
+}
+
+//An Index of the messages in a stream.
+//Empty lines are not considered messages and are not indexed.
+type Index struct {
+	Entries []Entry
+	//malformed messages, which are not in Entries
+	Skipped []gosmsg.SkippedRange
+	byTag   map[uint16][]int
+	byKey   map[string][]int
+}
+
+//Build scans r once and returns an Index of all the messages in it.
+//Messages that fail to parse are recorded in Index.Skipped and do not
+//stop the scan, only errors reading r are returned.
+func Build(r io.Reader) (*Index, error) {
+	return build(r, false, 0)
+}
+
+//BuildKeyed is like Build, but also indexes each message by the data of
+//the first occurrence of the field tag, including within constructors.
+//Messages without the field get an empty key.
+func BuildKeyed(r io.Reader, field uint16) (*Index, error) {
+	return build(r, true, field)
+}
+
+func build(r io.Reader, keyed bool, field uint16) (*Index, error) {
+	idx := &Index{byTag: map[uint16][]int{}}
+	if keyed {
+		idx.byKey = map[string][]int{}
+	}
+
+	br := bufio.NewReader(r)
+	var off int64
+	for {
+		l, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			//message longer than the buffer, fall back to a copy
+			var rest []byte
+			l = append([]byte{}, l...)
+			rest, err = br.ReadBytes('\n')
+			l = append(l, rest...)
+		}
+		if err != nil && err != io.EOF {
+			return idx, err
+		}
+
+		lineLen := len(l)
+		//same line endings as gosmsg.RawSMsgReader
+		for _, b := range []byte("\n\r") {
+			if len(l) > 0 && l[len(l)-1] == b {
+				l = l[:len(l)-1]
+			}
+		}
+
+		if len(l) > 0 {
+			if aerr := idx.add(l, off, keyed, field); aerr != nil {
+				idx.Skipped = append(idx.Skipped, gosmsg.SkippedRange{Offset: off, Len: len(l), Err: aerr})
+			}
+		}
+
+		off += int64(lineLen)
+		if err == io.EOF {
+			return idx, nil
+		}
+	}
+}
+
+func (idx *Index) add(l []byte, off int64, keyed bool, field uint16) error {
+	msg := gosmsg.RawSMsg{Data: l}
+	it := msg.Tags()
+	t, err := it.NextTag()
+	if err != nil {
+		return err
+	}
+
+	e := Entry{Offset: off, Length: len(l), Tag: t.Tag}
+	n := len(idx.Entries)
+	if keyed {
+		if kt, err := msg.Find(field, true); err == nil {
+			e.Key = string(kt.Data)
+		} else if err != gosmsg.ErrNotFound {
+			return err
+		}
+		idx.byKey[e.Key] = append(idx.byKey[e.Key], n)
+	}
+
+	idx.Entries = append(idx.Entries, e)
+	idx.byTag[e.Tag] = append(idx.byTag[e.Tag], n)
+	return nil
+}
+
+//Len returns the number of indexed messages
+func (idx *Index) Len() int {
+	return len(idx.Entries)
+}
+
+//ByTag returns the positions in Entries of all messages with the given
+//record tag
+func (idx *Index) ByTag(tag uint16) []int {
+	return idx.byTag[tag]
+}
+
+//ByKey returns the positions in Entries of all messages with the given
+//key. It always returns nil unless the Index was built with BuildKeyed
+func (idx *Index) ByKey(key string) []int {
+	return idx.byKey[key]
+}
+
+//IndexedReader reads messages at random positions using an Index
+type IndexedReader struct {
+	R     io.ReaderAt
+	Index *Index
+}
+
+//NewIndexedReader returns an IndexedReader reading messages from r,
+//which must be the same content idx was built from
+func NewIndexedReader(r io.ReaderAt, idx *Index) *IndexedReader {
+	return &IndexedReader{r, idx}
+}
+
+//ReadNth returns the nth message, counting from 0
+func (r *IndexedReader) ReadNth(n int) (gosmsg.RawSMsg, error) {
+	if n < 0 || n >= len(r.Index.Entries) {
+		return gosmsg.RawSMsg{}, fmt.Errorf("smsgindex: message %d out of range", n)
+	}
+
+	e := r.Index.Entries[n]
+	data := make([]byte, e.Length)
+	read, err := r.R.ReadAt(data, e.Offset)
+	if read < e.Length {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return gosmsg.RawSMsg{}, err
+	}
+	return gosmsg.RawSMsg{Data: data}, nil
+}
+
+//ReadPositions returns an iterator reading the messages at the given
+//positions, such as the ones returned by Index.ByTag
+func (r *IndexedReader) ReadPositions(positions []int) iter.Seq2[gosmsg.RawSMsg, error] {
+	return func(yield func(gosmsg.RawSMsg, error) bool) {
+		for _, n := range positions {
+			msg, err := r.ReadNth(n)
+			if !yield(msg, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+//ReadByTag returns an iterator reading all the messages with the given
+//record tag
+func (r *IndexedReader) ReadByTag(tag uint16) iter.Seq2[gosmsg.RawSMsg, error] {
+	return r.ReadPositions(r.Index.ByTag(tag))
+}
@@ -0,0 +1,156 @@
+package smsgindex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/noselasd/gosmsg"
+)
+
+const testData = "9019 00013 abc00000 \r\n" +
+	"\n" +
+	"9020 00013 def00000 \n" +
+	"9019 90019 00013 def00000 \n" +
+	"9019 00013 ghi00000 "
+
+//eofReaderAt returns io.EOF along with the data for reads that
+//end at end of input, as permitted by io.ReaderAt
+type eofReaderAt struct {
+	r *strings.Reader
+}
+
+func (r eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.r.ReadAt(p, off)
+	if err == nil && off+int64(n) == r.r.Size() {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func TestBuild(t *testing.T) {
+	idx, err := Build(strings.NewReader(testData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != 4 {
+		t.Fatalf("Got %d entries", idx.Len())
+	}
+
+	expOff := []int64{0, 23, 44, 71}
+	for i, e := range idx.Entries {
+		if e.Offset != expOff[i] {
+			t.Errorf("%d: Got offset %d expected %d", i, e.Offset, expOff[i])
+		}
+	}
+
+	if pos := idx.ByTag(0x1019); len(pos) != 3 || pos[0] != 0 || pos[1] != 2 || pos[2] != 3 {
+		t.Errorf("Got %v", pos)
+	}
+	if idx.ByKey("def") != nil {
+		t.Error("expected no key index")
+	}
+
+	r := NewIndexedReader(strings.NewReader(testData), idx)
+	msg, err := r.ReadNth(1)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(msg.Data) != "9020 00013 def00000 " {
+		t.Errorf("Got %q", msg.Data)
+	}
+
+	var got []string
+	for msg, err := range r.ReadByTag(0x1019) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(msg.Data))
+	}
+	if len(got) != 3 || got[0] != "9019 00013 abc00000 " || got[2] != "9019 00013 ghi00000 " {
+		t.Errorf("Got %q", got)
+	}
+
+	if _, err := r.ReadNth(4); err == nil {
+		t.Error("expected error")
+	}
+
+	//last message without a trailing newline ends at end of input,
+	//where ReaderAt may return io.EOF along with all the data
+	r = NewIndexedReader(eofReaderAt{strings.NewReader(testData)}, idx)
+	msg, err = r.ReadNth(3)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(msg.Data) != "9019 00013 ghi00000 " {
+		t.Errorf("Got %q", msg.Data)
+	}
+
+	short := NewIndexedReader(strings.NewReader(testData[:len(testData)-2]), idx)
+	if _, err := short.ReadNth(3); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestBuildKeyed(t *testing.T) {
+	idx, err := BuildKeyed(strings.NewReader(testData), 0x1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pos := idx.ByKey("def"); len(pos) != 2 || pos[0] != 1 || pos[1] != 2 {
+		t.Errorf("Got %v", pos)
+	}
+	if idx.Entries[2].Key != "def" {
+		t.Errorf("Got %q", idx.Entries[2].Key)
+	}
+}
+
+func TestBuildLong(t *testing.T) {
+	var b bytes.Buffer
+	var msg gosmsg.RawSMsg
+	msg.Add(0x1, bytes.Repeat([]byte("x"), 10000))
+	for i := 0; i < 3; i++ {
+		b.Write(msg.Data)
+		b.WriteByte('\n')
+	}
+
+	idx, err := Build(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != 3 || idx.Entries[2].Offset != int64(2*(len(msg.Data)+1)) {
+		t.Errorf("Got %v", idx.Entries)
+	}
+}
+
+func TestBuildLineEndings(t *testing.T) {
+	in := "00011 a\r\n00011 b\r"
+	idx, err := Build(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != 2 || idx.Entries[0].Length != 7 || idx.Entries[1].Length != 7 {
+		t.Errorf("Got %v", idx.Entries)
+	}
+}
+
+func TestBuildErr(t *testing.T) {
+	in := "9019 00013 ab\nH001\n00011 x\n10012 hi\n"
+	idx, err := BuildKeyed(strings.NewReader(in), 0x1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != 2 || idx.Entries[0].Offset != 19 || idx.Entries[1].Offset != 27 {
+		t.Errorf("Got %v", idx.Entries)
+	}
+
+	var perr *gosmsg.ParseError
+	if len(idx.Skipped) != 2 || idx.Skipped[0].Offset != 0 || idx.Skipped[0].Len != 13 ||
+		idx.Skipped[1].Offset != 14 || idx.Skipped[1].Len != 4 || !errors.As(idx.Skipped[1].Err, &perr) {
+		t.Errorf("Got %+v", idx.Skipped)
+	}
+	if pos := idx.ByTag(0x1001); len(pos) != 1 || pos[0] != 1 {
+		t.Errorf("Got %v", pos)
+	}
+}
@@ -0,0 +1,93 @@
+package gosmsg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+//ErrUnterminated is returned when a variable length constructor
+//is not terminated by an empty 0x0000 tag
+var ErrUnterminated = errors.New("gosmsg: unterminated variable length constructor")
+
+//ErrVarLenPrimitive is returned when a tag without the constructor bit
+//has no length
+var ErrVarLenPrimitive = errors.New("gosmsg: variable length primitive tag")
+
+//A ValidationError describes the first structural problem found by Validate
+type ValidationError struct {
+	//Offset in RawSMsg.Data of the problem
+	Offset int
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("gosmsg: invalid SMsg at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+//gValidateMaxDepth is the constructor nesting depth allowed by Validate
+const gValidateMaxDepth = 256
+
+//Validate checks the structure of the entire SMsg, including the content
+//of nested constructors. It checks the tag syntax, that the lengths
+//are consistent, that variable length constructors are terminated and
+//that no newlines are present.
+//Constructors may be nested at most 256 deep, see ValidateWithLimits.
+//A *ValidationError describing the first problem found is returned.
+func (s *RawSMsg) Validate() error {
+	return s.ValidateWithLimits(Limits{MaxDepth: gValidateMaxDepth})
+}
+
+//ValidateWithLimits is like Validate, but with the limits in l instead
+//of the default nesting depth. When a limit is exceeded the
+//*ValidationError wraps a *LimitExceededError.
+func (s *RawSMsg) ValidateWithLimits(l Limits) error {
+	if idx := bytes.IndexAny(s.Data, "\r\n"); idx != -1 {
+		return &ValidationError{idx, ErrEmbeddedNewline}
+	}
+	return validateTags(s.Data, 0, 0, &iterState{Limits: l})
+}
+
+//validateTags validates the tags in data, base is the offset of data
+//within the entire SMsg and depth its nesting depth
+func validateTags(data []byte, base int, depth int, st *iterState) error {
+	var open []int //offsets of open variable length constructors
+	i := Iter{data: data, off: base, st: st, depth: depth}
+	for {
+		off := i.off
+		if len(i.data) > 0 && len(i.data) < 4 {
+			return &ValidationError{off, io.ErrShortBuffer}
+		}
+
+		t, err := i.NextTag()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return &ValidationError{off, err}
+		}
+
+		switch {
+		case t.VarLen && !t.Constructor:
+			return &ValidationError{off, ErrVarLenPrimitive}
+		case t.VarLen:
+			open = append(open, off)
+		case t.Constructor:
+			if err := validateTags(t.Data, t.dataOff, t.depth+1, st); err != nil {
+				return err
+			}
+		case t.Tag == 0 && len(t.Data) == 0 && len(open) > 0:
+			open = open[:len(open)-1]
+		}
+	}
+
+	if len(open) > 0 {
+		return &ValidationError{open[len(open)-1], ErrUnterminated}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package gosmsg
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"",
+		"12345 Hello00101 8000A0 0F072 \"\"",
+		"9019 922211 12345 Hello00101 800000 ",
+		"901918 12345 Hello00101 800000 ",
+		"9019 9020 00000 00000 ",
+	}
+	for _, v := range valid {
+		r := RawSMsg{[]byte(v)}
+		if err := r.Validate(); err != nil {
+			t.Errorf("%q: %v", v, err)
+		}
+	}
+
+	invalid := []struct {
+		data   string
+		offset int
+		err    error
+	}{
+		{"12345 Hello001", 11, io.ErrShortBuffer},
+		{"12345 Hello0010", 11, io.ErrShortBuffer},
+		{"12345 Hello0010 8", 11, ErrVarLenPrimitive},
+		{"12345 Hello9019 00101 8", 11, ErrUnterminated},
+		{"9019 9020 00000 ", 0, ErrUnterminated},
+		{"12345 Hello10014 hi ", 11, io.ErrShortBuffer},
+		{"90198 1001-2 hi", 6, strconv.ErrRange},
+		{"901912 9020 00101 8", 7, ErrUnterminated},
+		{"12345 He\nlo", 8, ErrEmbeddedNewline},
+	}
+	for _, v := range invalid {
+		r := RawSMsg{[]byte(v.data)}
+		err := r.Validate()
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%q: expected ValidationError, got %v", v.data, err)
+			continue
+		}
+		if verr.Offset != v.offset || !errors.Is(err, v.err) {
+			t.Errorf("%q: got %v, expected offset %d %v", v.data, err, v.offset, v.err)
+		}
+		var perr *ParseError
+		if errors.As(err, &perr) && perr.Offset != v.offset {
+			t.Errorf("%q: got ParseError offset %d, expected %d", v.data, perr.Offset, v.offset)
+		}
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	//fixed length constructors nested 300 deep
+	data := "00012 hi"
+	for i := 0; i < 300; i++ {
+		data = "8001" + strconv.Itoa(len(data)) + " " + data
+	}
+	r := RawSMsg{[]byte(data)}
+
+	var lerr *LimitExceededError
+	var verr *ValidationError
+	err := r.Validate()
+	if !errors.As(err, &lerr) || lerr.Limit != "MaxDepth" || lerr.Max != 256 || !errors.As(err, &verr) {
+		t.Errorf("Got %v", err)
+	}
+	if err := r.ValidateWithLimits(Limits{}); err != nil {
+		t.Error(err)
+	}
+
+	r = RawSMsg{[]byte("9019 9020 00101 800000 00000 ")}
+	if err := r.ValidateWithLimits(Limits{MaxDepth: 2}); err != nil {
+		t.Error(err)
+	}
+	err = r.ValidateWithLimits(Limits{MaxDepth: 1})
+	if !errors.As(err, &verr) || verr.Offset != 5 || !errors.As(err, &lerr) {
+		t.Errorf("Got %v", err)
+	}
+	err = r.ValidateWithLimits(Limits{MaxTags: 4})
+	if !errors.As(err, &verr) || verr.Offset != 23 || !errors.As(err, &lerr) || lerr.Limit != "MaxTags" {
+		t.Errorf("Got %v", err)
+	}
+}
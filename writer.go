@@ -0,0 +1,115 @@
+package gosmsg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+//ErrEmbeddedNewline is returned when a RawSMsg to be written contains a
+//newline (\r or \n) within its data, which would break the framing.
+var ErrEmbeddedNewline = errors.New("gosmsg: newline within SMsg data")
+
+//RawSMsgWriter is used to write RawSMsgs to a stream.
+//Each message is terminated by a newline. Output is buffered,
+//call Flush when done writing.
+type RawSMsgWriter struct {
+	//writer to write SMsgs to
+	W         *bufio.Writer
+	closer    io.Closer
+	scratch   RawSMsg
+	bytes     int64
+	messages  int64
+	lastError error
+}
+
+//NewRawSMsgWriter returns a new RawSMsgWriter writing to w.
+//w is wrapped in a *bufio.Writer unless it already is a *bufio.Writer
+func NewRawSMsgWriter(w io.Writer) RawSMsgWriter {
+	ww := RawSMsgWriter{}
+	if bufW, ok := w.(*bufio.Writer); ok {
+		ww.W = bufW
+	} else {
+		ww.W = bufio.NewWriter(w)
+	}
+	return ww
+}
+
+//WriteRawSMsg writes s followed by a newline.
+//A trailing \r\n or \n already present in s is not duplicated, any other
+//newline within s results in ErrEmbeddedNewline.
+//Once a write error occurs, all subsequent writes return that error.
+func (w *RawSMsgWriter) WriteRawSMsg(s RawSMsg) error {
+	if w.lastError != nil {
+		return w.lastError
+	}
+
+	l := s.Data
+	if len(l) > 0 && l[len(l)-1] == '\n' {
+		l = l[:len(l)-1]
+		if len(l) > 0 && l[len(l)-1] == '\r' {
+			l = l[:len(l)-1]
+		}
+	}
+	if bytes.IndexAny(l, "\r\n") != -1 {
+		return ErrEmbeddedNewline
+	}
+
+	n, err := w.W.Write(l)
+	w.bytes += int64(n)
+	if err == nil {
+		err = w.W.WriteByte('\n')
+		if err == nil {
+			w.bytes++
+			w.messages++
+		}
+	}
+
+	w.lastError = err
+	return err
+}
+
+//WriteFunc calls build with an empty RawSMsg and writes the result.
+//The RawSMsg passed to build is reused between calls and must not
+//be retained.
+func (w *RawSMsgWriter) WriteFunc(build func(s *RawSMsg)) error {
+	w.scratch.Data = w.scratch.Data[:0]
+	build(&w.scratch)
+	return w.WriteRawSMsg(w.scratch)
+}
+
+//Flush writes any buffered data to the underlying io.Writer.
+func (w *RawSMsgWriter) Flush() error {
+	if w.lastError != nil {
+		return w.lastError
+	}
+	w.lastError = w.W.Flush()
+	return w.lastError
+}
+
+//Close flushes any buffered data and finishes the compressed stream
+//if the writer compresses its output. The underlying io.Writer is
+//not closed.
+func (w *RawSMsgWriter) Close() error {
+	err := w.Flush()
+	if w.closer != nil {
+		if cerr := w.closer.Close(); err == nil {
+			err = cerr
+		}
+		w.closer = nil
+	}
+	return err
+}
+
+//Bytes returns the number of bytes written, including newlines.
+//Bytes still in the buffer are included. For compressing writers
+//this is the uncompressed size.
+func (w *RawSMsgWriter) Bytes() int64 {
+	return w.bytes
+}
+
+//Messages returns the number of messages written.
+func (w *RawSMsgWriter) Messages() int64 {
+	return w.messages
+}
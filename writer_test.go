@@ -0,0 +1,79 @@
+package gosmsg
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var b bytes.Buffer
+
+	w := NewRawSMsgWriter(&b)
+	if err := w.WriteRawSMsg(RawSMsg{[]byte("10015 hello")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRawSMsg(RawSMsg{[]byte("10015 hello\r\n")}); err != nil {
+		t.Fatal(err)
+	}
+	err := w.WriteFunc(func(s *RawSMsg) {
+		s.Add(0x1001, []byte("hello"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Len() != 0 {
+		t.Errorf("expected buffered output, got %q", b.String())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "10015 hello\n10015 hello\n10015 hello\n"
+	if b.String() != exp {
+		t.Errorf("Got %q expected %q", b.String(), exp)
+	}
+	if w.Messages() != 3 {
+		t.Errorf("Got %d messages", w.Messages())
+	}
+	if w.Bytes() != int64(len(exp)) {
+		t.Errorf("Got %d bytes", w.Bytes())
+	}
+
+	r := NewRawSMsgReader(&b)
+	for i := 0; i < 3; i++ {
+		smsg, err := r.ReadRawSMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(smsg.Data) != "10015 hello" {
+			t.Errorf("Got %q", smsg.Data)
+		}
+	}
+	if _, err := r.ReadRawSMsg(); err != io.EOF {
+		t.Fatal(err)
+	}
+}
+
+func TestWriterNewline(t *testing.T) {
+	var b bytes.Buffer
+
+	w := NewRawSMsgWriter(&b)
+	if err := w.WriteRawSMsg(RawSMsg{[]byte("10015 he\nlo")}); err != ErrEmbeddedNewline {
+		t.Errorf("expected ErrEmbeddedNewline, got %v", err)
+	}
+	if err := w.WriteRawSMsg(RawSMsg{[]byte("10015 he\rlo")}); err != ErrEmbeddedNewline {
+		t.Errorf("expected ErrEmbeddedNewline, got %v", err)
+	}
+	if err := w.WriteRawSMsg(RawSMsg{[]byte("00012 a\r")}); err != ErrEmbeddedNewline {
+		t.Errorf("expected ErrEmbeddedNewline, got %v", err)
+	}
+	if err := w.WriteRawSMsg(RawSMsg{[]byte("00012 a\n\n")}); err != ErrEmbeddedNewline {
+		t.Errorf("expected ErrEmbeddedNewline, got %v", err)
+	}
+	w.Flush()
+	if b.Len() != 0 || w.Messages() != 0 {
+		t.Errorf("unexpected output %q", b.String())
+	}
+}